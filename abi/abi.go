@@ -0,0 +1,152 @@
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type Type string
+
+const (
+	TypeU8      Type = "u8"
+	TypeU16     Type = "u16"
+	TypeU32     Type = "u32"
+	TypeU64     Type = "u64"
+	TypeI64     Type = "i64"
+	TypeBool    Type = "bool"
+	TypeString  Type = "string"
+	TypeAddress Type = "address"
+	TypeAmount  Type = "amount"
+	TypeBytes   Type = "bytes"
+	// TypeVoid is used as a method output only
+	TypeVoid Type = ""
+)
+
+func (t Type) IsValid() bool {
+	switch t {
+	case TypeU8, TypeU16, TypeU32, TypeU64, TypeI64, TypeBool, TypeString, TypeAddress, TypeAmount, TypeBytes:
+		return true
+	default:
+		return false
+	}
+}
+
+type Argument struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Nullable arguments accept nil which is passed to the contract as a nil argument
+	Nullable bool `json:"nullable,omitempty"`
+}
+
+type Method struct {
+	Name    string     `json:"name"`
+	Inputs  []Argument `json:"inputs"`
+	Output  Type       `json:"output,omitempty"`
+	Payable bool       `json:"payable,omitempty"`
+	View    bool       `json:"view,omitempty"`
+}
+
+type Event struct {
+	Name string     `json:"name"`
+	Args []Argument `json:"args"`
+}
+
+// ABI describes exported methods and emitted events of a contract. The deploy method describes deploy arguments.
+type ABI struct {
+	Name    string   `json:"name,omitempty"`
+	Version string   `json:"version,omitempty"`
+	Methods []Method `json:"methods"`
+	Events  []Event  `json:"events,omitempty"`
+}
+
+func Parse(data []byte) (*ABI, error) {
+	res := &ABI{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func Load(path string) (*ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func (a *ABI) Marshal() ([]byte, error) {
+	return json.MarshalIndent(a, "", "  ")
+}
+
+func (a *ABI) Validate() error {
+	methods := map[string]struct{}{}
+	for _, m := range a.Methods {
+		if m.Name == "" {
+			return fmt.Errorf("method without name")
+		}
+		if _, ok := methods[m.Name]; ok {
+			return fmt.Errorf("duplicate method %v", m.Name)
+		}
+		methods[m.Name] = struct{}{}
+		if err := validateArguments(m.Inputs); err != nil {
+			return fmt.Errorf("method %v: %w", m.Name, err)
+		}
+		if m.Output != TypeVoid && !m.Output.IsValid() {
+			return fmt.Errorf("method %v: unknown output type %q", m.Name, m.Output)
+		}
+	}
+	events := map[string]struct{}{}
+	for _, e := range a.Events {
+		if e.Name == "" {
+			return fmt.Errorf("event without name")
+		}
+		if _, ok := events[e.Name]; ok {
+			return fmt.Errorf("duplicate event %v", e.Name)
+		}
+		events[e.Name] = struct{}{}
+		if err := validateArguments(e.Args); err != nil {
+			return fmt.Errorf("event %v: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateArguments(arguments []Argument) error {
+	names := map[string]struct{}{}
+	for idx, arg := range arguments {
+		if !arg.Type.IsValid() {
+			return fmt.Errorf("argument %v: unknown type %q", idx, arg.Type)
+		}
+		if arg.Name == "" {
+			continue
+		}
+		if _, ok := names[arg.Name]; ok {
+			return fmt.Errorf("duplicate argument %v", arg.Name)
+		}
+		names[arg.Name] = struct{}{}
+	}
+	return nil
+}
+
+func (a *ABI) Method(name string) (*Method, bool) {
+	for i := range a.Methods {
+		if a.Methods[i].Name == name {
+			return &a.Methods[i], true
+		}
+	}
+	return nil, false
+}
+
+func (a *ABI) Event(name string) (*Event, bool) {
+	for i := range a.Events {
+		if a.Events[i].Name == name {
+			return &a.Events[i], true
+		}
+	}
+	return nil, false
+}
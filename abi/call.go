@@ -0,0 +1,41 @@
+package abi
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+)
+
+// CallABI encodes values according to the method description, executes the method and decodes its output.
+func CallABI(api *lib.GoAPI, code []byte, contractABI *ABI, contract lib.Address, gasLimit uint64, method string, values ...interface{}) (interface{}, *lib.ExecutionResult, error) {
+	m, ok := contractABI.Method(method)
+	if !ok {
+		return nil, nil, fmt.Errorf("method %v is not described in abi", method)
+	}
+	encoded, err := m.EncodeInputs(values...)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := lib.ExecuteResult(api, code, method, encoded, contract, gasLimit, api.IsDebug())
+	if err != nil {
+		return nil, result, err
+	}
+	output, err := m.DecodeOutput(result.OutputData)
+	if err != nil {
+		return nil, result, fmt.Errorf("method %v: cannot decode output: %w", method, err)
+	}
+	return output, result, nil
+}
+
+// DeployABI deploys the contract with arguments described by the deploy method, the contract may be deployed
+// without arguments if the deploy method is not described.
+func DeployABI(api *lib.GoAPI, code []byte, contractABI *ABI, contract lib.Address, gasLimit uint64, values ...interface{}) (*lib.ExecutionResult, error) {
+	var arguments []Argument
+	if m, ok := contractABI.Method("deploy"); ok {
+		arguments = m.Inputs
+	}
+	encoded, err := EncodeArguments(arguments, values...)
+	if err != nil {
+		return nil, fmt.Errorf("deploy: %w", err)
+	}
+	return lib.DeployResult(api, code, encoded, contract, gasLimit, api.IsDebug())
+}
@@ -0,0 +1,285 @@
+package abi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/args"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// Encode converts a Go value to the ABI type and encodes it with the args codec.
+// Integers of any Go integer type are accepted if they fit into the ABI type,
+// addresses and bytes may also be passed as 0x-prefixed hex strings.
+func Encode(t Type, value interface{}) ([]byte, error) {
+	switch t {
+	case TypeU8:
+		v, err := toUint(value, math.MaxUint8)
+		return args.U8(uint8(v)), err
+	case TypeU16:
+		v, err := toUint(value, math.MaxUint16)
+		return args.U16(uint16(v)), err
+	case TypeU32:
+		v, err := toUint(value, math.MaxUint32)
+		return args.U32(uint32(v)), err
+	case TypeU64:
+		v, err := toUint(value, math.MaxUint64)
+		return args.U64(v), err
+	case TypeI64:
+		v, err := toInt(value)
+		return args.I64(v), err
+	case TypeBool:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, typeError(t, value)
+		}
+		return args.Bool(v), nil
+	case TypeString:
+		v, ok := value.(string)
+		if !ok {
+			return nil, typeError(t, value)
+		}
+		return args.String(v), nil
+	case TypeAddress:
+		return toAddress(value)
+	case TypeAmount:
+		v, err := toBigInt(value)
+		if err != nil {
+			return nil, err
+		}
+		return args.Encode(v)
+	case TypeBytes:
+		return toBytes(value)
+	default:
+		return nil, fmt.Errorf("unknown type %q", t)
+	}
+}
+
+// Decode decodes an encoded value of the ABI type to the corresponding Go type:
+// uint8, uint16, uint32, uint64, int64, bool, string, lib.Address, *big.Int or []byte.
+func Decode(t Type, data []byte) (interface{}, error) {
+	switch t {
+	case TypeU8:
+		return args.ToU8(data)
+	case TypeU16:
+		return args.ToU16(data)
+	case TypeU32:
+		return args.ToU32(data)
+	case TypeU64:
+		return args.ToU64(data)
+	case TypeI64:
+		return args.ToI64(data)
+	case TypeBool:
+		return args.ToBool(data)
+	case TypeString:
+		return args.ToString(data)
+	case TypeAddress:
+		return args.ToAddress(data)
+	case TypeAmount:
+		return args.ToBigInt(data)
+	case TypeBytes:
+		return data, nil
+	case TypeVoid:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", t)
+	}
+}
+
+// EncodeArguments validates values against the arguments description and encodes them.
+func EncodeArguments(arguments []Argument, values ...interface{}) ([][]byte, error) {
+	if len(values) != len(arguments) {
+		return nil, fmt.Errorf("expected %v arguments, got %v", len(arguments), len(values))
+	}
+	res := make([][]byte, 0, len(values))
+	for idx, arg := range arguments {
+		value := values[idx]
+		if isNil(value) {
+			if !arg.Nullable {
+				return nil, fmt.Errorf("argument %v: nil is not allowed", argumentName(arg, idx))
+			}
+			res = append(res, nil)
+			continue
+		}
+		encoded, err := Encode(arg.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %v: %w", argumentName(arg, idx), err)
+		}
+		res = append(res, encoded)
+	}
+	return res, nil
+}
+
+// DecodeArguments decodes arguments according to the description, nil arguments are decoded as nil
+// if the argument is nullable and fail otherwise. Empty arguments are decoded like by Decode,
+// so they are valid amounts, strings and bytes only.
+func DecodeArguments(arguments []Argument, data [][]byte) ([]interface{}, error) {
+	if len(data) != len(arguments) {
+		return nil, fmt.Errorf("expected %v arguments, got %v", len(arguments), len(data))
+	}
+	res := make([]interface{}, 0, len(data))
+	for idx, arg := range arguments {
+		if data[idx] == nil {
+			if !arg.Nullable {
+				return nil, fmt.Errorf("argument %v: %w", argumentName(arg, idx), args.ErrNilArgument)
+			}
+			res = append(res, nil)
+			continue
+		}
+		value, err := Decode(arg.Type, data[idx])
+		if err != nil {
+			return nil, fmt.Errorf("argument %v: %w", argumentName(arg, idx), err)
+		}
+		res = append(res, value)
+	}
+	return res, nil
+}
+
+func (m *Method) EncodeInputs(values ...interface{}) ([][]byte, error) {
+	res, err := EncodeArguments(m.Inputs, values...)
+	if err != nil {
+		return nil, fmt.Errorf("method %v: %w", m.Name, err)
+	}
+	return res, nil
+}
+
+func (m *Method) DecodeOutput(data []byte) (interface{}, error) {
+	if m.Output == TypeVoid {
+		return nil, nil
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return Decode(m.Output, data)
+}
+
+func (e *Event) DecodeArgs(data [][]byte) ([]interface{}, error) {
+	res, err := DecodeArguments(e.Args, data)
+	if err != nil {
+		return nil, fmt.Errorf("event %v: %w", e.Name, err)
+	}
+	return res, nil
+}
+
+func argumentName(arg Argument, idx int) string {
+	if arg.Name != "" {
+		return arg.Name
+	}
+	return fmt.Sprint(idx)
+}
+
+func typeError(t Type, value interface{}) error {
+	return fmt.Errorf("cannot use %T as %v", value, t)
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+func toUint(value interface{}, max uint64) (uint64, error) {
+	v := reflect.ValueOf(value)
+	var res uint64
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		res = v.Uint()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return 0, fmt.Errorf("negative value %v", v.Int())
+		}
+		res = uint64(v.Int())
+	default:
+		return 0, fmt.Errorf("cannot use %T as unsigned integer", value)
+	}
+	if res > max {
+		return 0, fmt.Errorf("value %v overflows %v", res, max)
+	}
+	return res, nil
+}
+
+func toInt(value interface{}) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value %v overflows i64", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	default:
+		return 0, fmt.Errorf("cannot use %T as signed integer", value)
+	}
+}
+
+func toBigInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case big.Int:
+		return &v, nil
+	case string:
+		res, ok := new(big.Int).SetString(v, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", v)
+		}
+		return res, nil
+	default:
+		i, err := toUint(value, math.MaxUint64)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetUint64(i), nil
+	}
+}
+
+func toAddress(value interface{}) ([]byte, error) {
+	var data []byte
+	switch v := value.(type) {
+	case lib.Address:
+		return args.Address(v), nil
+	case []byte:
+		data = v
+	case string:
+		var err error
+		if data, err = decodeHex(v); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, typeError(TypeAddress, value)
+	}
+	addr, err := args.ToAddress(data)
+	if err != nil {
+		return nil, err
+	}
+	return args.Address(addr), nil
+}
+
+func toBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return decodeHex(v)
+	default:
+		return nil, typeError(TypeBytes, value)
+	}
+}
+
+func decodeHex(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("hex string %q must have 0x prefix", s)
+	}
+	return hex.DecodeString(s[2:])
+}
@@ -0,0 +1,376 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type Options struct {
+	// Package is the package name of the generated file
+	Package string
+	// Type is the name of the generated client type, it defaults to the camel-cased ABI name
+	Type string
+}
+
+type typeInfo struct {
+	GoType      string
+	Encode      string
+	DecodeBytes string
+	Decoder     string
+}
+
+var types = map[abi.Type]typeInfo{
+	abi.TypeU8:      {"uint8", "args.U8(%v)", "args.ToU8(%v)", "U8()"},
+	abi.TypeU16:     {"uint16", "args.U16(%v)", "args.ToU16(%v)", "U16()"},
+	abi.TypeU32:     {"uint32", "args.U32(%v)", "args.ToU32(%v)", "U32()"},
+	abi.TypeU64:     {"uint64", "args.U64(%v)", "args.ToU64(%v)", "U64()"},
+	abi.TypeI64:     {"int64", "args.I64(%v)", "args.ToI64(%v)", "I64()"},
+	abi.TypeBool:    {"bool", "args.Bool(%v)", "args.ToBool(%v)", "Bool()"},
+	abi.TypeString:  {"string", "args.String(%v)", "args.ToString(%v)", "String()"},
+	abi.TypeAddress: {"lib.Address", "args.Address(%v)", "args.ToAddress(%v)", "Address()"},
+	abi.TypeAmount:  {"*big.Int", "args.BigInt(%v)", "args.ToBigInt(%v)", "BigInt()"},
+	abi.TypeBytes:   {"[]byte", "%v", "", "Bytes()"},
+}
+
+type param struct {
+	Name      string
+	Field     string
+	GoType    string
+	Encode    string
+	Decoder   string
+	Nullable  bool
+	ByPointer bool
+}
+
+type method struct {
+	Name      string
+	GoName    string
+	Params    []param
+	Output    string
+	Decode    string
+	HasOutput bool
+}
+
+type event struct {
+	Name   string
+	GoName string
+	Fields []param
+}
+
+type data struct {
+	Package string
+	Type    string
+	ABI     string
+	Deploy  *method
+	Methods []method
+	Events  []event
+}
+
+// Generate returns formatted Go source of a typed client for the contract described by the ABI.
+func Generate(contractABI *abi.ABI, opts Options) ([]byte, error) {
+	if err := contractABI.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+	typeName := opts.Type
+	if typeName == "" {
+		typeName = exportedName(contractABI.Name)
+	}
+	if typeName == "" {
+		return nil, fmt.Errorf("type name is required for an ABI without name")
+	}
+	abiJson, err := json.Marshal(contractABI)
+	if err != nil {
+		return nil, err
+	}
+	d := data{
+		Package: opts.Package,
+		Type:    typeName,
+		ABI:     string(abiJson),
+	}
+	decls := goNames{
+		typeName:                   "the client type",
+		typeName + "ABI":           "the ABI constant",
+		"Parse" + typeName + "ABI": "the ABI parser",
+		"New" + typeName:           "the client constructor",
+	}
+	clientMethods := goNames{"Deploy": "the deploy method"}
+	for _, m := range contractABI.Methods {
+		converted := convertMethod(m)
+		if m.Name == "deploy" {
+			d.Deploy = &converted
+			continue
+		}
+		if err := clientMethods.add(converted.GoName, "method "+m.Name); err != nil {
+			return nil, err
+		}
+		d.Methods = append(d.Methods, converted)
+	}
+	for _, e := range contractABI.Events {
+		goName := exportedName(e.Name)
+		if goName == "" {
+			return nil, fmt.Errorf("event %v has no Go name", e.Name)
+		}
+		converted := event{
+			Name:   e.Name,
+			GoName: typeName + goName + "Event",
+			Fields: convertParams(e.Args),
+		}
+		if err := decls.add(converted.GoName, "event "+e.Name); err != nil {
+			return nil, err
+		}
+		if err := decls.add("Decode"+converted.GoName, "decoder of event "+e.Name); err != nil {
+			return nil, err
+		}
+		fields := goNames{}
+		for idx, field := range converted.Fields {
+			if err := fields.add(field.Field, fmt.Sprintf("event %v argument %v", e.Name, argumentName(e.Args[idx], idx))); err != nil {
+				return nil, err
+			}
+		}
+		d.Events = append(d.Events, converted)
+	}
+	buf := new(bytes.Buffer)
+	if err := clientTemplate.Execute(buf, d); err != nil {
+		return nil, err
+	}
+	res, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot format generated code: %w", err)
+	}
+	return res, nil
+}
+
+func convertMethod(m abi.Method) method {
+	res := method{
+		Name:   m.Name,
+		GoName: exportedName(m.Name),
+		Params: convertParams(m.Inputs),
+	}
+	if m.Output != abi.TypeVoid {
+		info := types[m.Output]
+		res.HasOutput = true
+		res.Output = info.GoType
+		if info.DecodeBytes != "" {
+			res.Decode = fmt.Sprintf(info.DecodeBytes, "result.OutputData")
+		}
+	}
+	return res
+}
+
+func convertParams(arguments []abi.Argument) []param {
+	used := map[string]bool{"api": true, "c": true, "result": true, "err": true, "output": true, "callArgs": true}
+	var res []param
+	for idx, arg := range arguments {
+		info := types[arg.Type]
+		name := unexportedName(arg.Name)
+		if name == "" {
+			name = fmt.Sprintf("arg%v", idx)
+		}
+		for used[name] || token.IsKeyword(name) || name == "args" || name == "lib" || name == "big" {
+			name += "_"
+		}
+		used[name] = true
+		field := exportedName(arg.Name)
+		if field == "" {
+			field = fmt.Sprintf("Arg%v", idx)
+		}
+		p := param{
+			Name:     name,
+			Field:    field,
+			GoType:   info.GoType,
+			Decoder:  info.Decoder,
+			Nullable: arg.Nullable,
+		}
+		value := name
+		if arg.Nullable && !strings.HasPrefix(info.GoType, "*") && !strings.HasPrefix(info.GoType, "[]") {
+			p.ByPointer = true
+			p.GoType = "*" + info.GoType
+			value = "*" + name
+		}
+		p.Encode = fmt.Sprintf(info.Encode, value)
+		res = append(res, p)
+	}
+	return res
+}
+
+// goNames maps generated Go identifiers to descriptions of what they are generated from,
+// ABI names become the same identifier if they differ only in case of the first letter or in separators.
+type goNames map[string]string
+
+func (n goNames) add(goName string, source string) error {
+	if goName == "" {
+		return fmt.Errorf("%v has no Go name", source)
+	}
+	if other, ok := n[goName]; ok {
+		return fmt.Errorf("%v collides with %v as %v", source, other, goName)
+	}
+	n[goName] = source
+	return nil
+}
+
+func argumentName(arg abi.Argument, idx int) string {
+	if arg.Name == "" {
+		return fmt.Sprint(idx)
+	}
+	return arg.Name
+}
+
+func splitWords(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func exportedName(name string) string {
+	var sb strings.Builder
+	for _, word := range splitWords(name) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	res := sb.String()
+	if res != "" && unicode.IsDigit([]rune(res)[0]) {
+		res = "X" + res
+	}
+	return res
+}
+
+func unexportedName(name string) string {
+	res := []rune(exportedName(name))
+	if len(res) == 0 {
+		return ""
+	}
+	if res[0] == 'X' && len(res) > 1 && unicode.IsDigit(res[1]) {
+		res[0] = 'x'
+		return string(res)
+	}
+	res[0] = unicode.ToLower(res[0])
+	return string(res)
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by abigen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"github.com/idena-network/idena-wasm-binding/args"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+)
+
+var (
+	_ = big.NewInt
+	_ = args.NewDecoder
+)
+
+// {{.Type}}ABI is the ABI the client was generated from.
+const {{.Type}}ABI = {{printf "%q" .ABI}}
+
+func Parse{{.Type}}ABI() (*abi.ABI, error) {
+	return abi.Parse([]byte({{.Type}}ABI))
+}
+
+// {{.Type}} is a typed client of the contract.
+// Fields are unexported so that they cannot collide with methods generated from the ABI.
+type {{.Type}} struct {
+	code     []byte
+	address  lib.Address
+	gasLimit uint64
+}
+
+func New{{.Type}}(code []byte, address lib.Address, gasLimit uint64) *{{.Type}} {
+	return &{{.Type}}{
+		code:     code,
+		address:  address,
+		gasLimit: gasLimit,
+	}
+}
+{{with .Deploy}}
+func (c *{{$.Type}}) Deploy(api *lib.GoAPI{{range .Params}}, {{.Name}} {{.GoType}}{{end}}) (*lib.ExecutionResult, error) {
+	callArgs := [][]byte{}
+	{{- template "pack" .Params}}
+	return lib.DeployResult(api, c.code, callArgs, c.address, c.gasLimit, api.IsDebug())
+}
+{{else}}
+func (c *{{$.Type}}) Deploy(api *lib.GoAPI) (*lib.ExecutionResult, error) {
+	return lib.DeployResult(api, c.code, [][]byte{}, c.address, c.gasLimit, api.IsDebug())
+}
+{{end}}
+{{- range .Methods}}
+// {{.GoName}} calls the {{.Name}} method of the contract.
+func (c *{{$.Type}}) {{.GoName}}(api *lib.GoAPI{{range .Params}}, {{.Name}} {{.GoType}}{{end}}) ({{if .HasOutput}}{{.Output}}, {{end}}*lib.ExecutionResult, error) {
+	callArgs := [][]byte{}
+	{{- template "pack" .Params}}
+	result, err := lib.ExecuteResult(api, c.code, {{printf "%q" .Name}}, callArgs, c.address, c.gasLimit, api.IsDebug())
+	{{- if .HasOutput}}
+	if err != nil || result.OutputData == nil {
+		var empty {{.Output}}
+		return empty, result, err
+	}
+	{{- if .Decode}}
+	output, err := {{.Decode}}
+	return output, result, err
+	{{- else}}
+	return result.OutputData, result, nil
+	{{- end}}
+	{{- else}}
+	return result, err
+	{{- end}}
+}
+{{end}}
+{{- range .Events}}
+// {{.GoName}} holds arguments of the {{.Name}} event.
+type {{.GoName}} struct {
+	{{- range .Fields}}
+	{{.Field}} {{.GoType}}
+	{{- end}}
+}
+
+// Decode{{.GoName}} decodes arguments of the {{.Name}} event as passed to HostEnv.Event.
+func Decode{{.GoName}}(eventArgs [][]byte) (*{{.GoName}}, error) {
+	d := args.NewDecoder(eventArgs)
+	res := &{{.GoName}}{}
+	{{- range .Fields}}
+	{{- if .Nullable}}
+	if d.IsNil() {
+		d.Raw()
+	} else {
+		{{if .ByPointer}}v := d.{{.Decoder}}
+		res.{{.Field}} = &v{{else}}res.{{.Field}} = d.{{.Decoder}}{{end}}
+	}
+	{{- else}}
+	res.{{.Field}} = d.{{.Decoder}}
+	{{- end}}
+	{{- end}}
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+{{end}}
+
+{{- define "pack"}}
+	{{- range .}}
+	{{- if .Nullable}}
+	if {{.Name}} == nil {
+		callArgs = append(callArgs, nil)
+	} else {
+		callArgs = append(callArgs, {{.Encode}})
+	}
+	{{- else}}
+	callArgs = append(callArgs, {{.Encode}})
+	{{- end}}
+	{{- end}}
+{{- end}}
+`))
@@ -0,0 +1,88 @@
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/wasm"
+)
+
+// MetadataSection is the name of the custom section carrying contract metadata in the code.
+const MetadataSection = "idena:abi"
+
+var ErrNoMetadata = errors.New("code has no metadata")
+
+// Metadata travels with the contract code, so explorers can verify and describe a deployed contract.
+type Metadata struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	ABI     *ABI   `json:"abi,omitempty"`
+	// SourceHash is the 0x-prefixed hash of the contract sources the code is built from
+	SourceHash string `json:"sourceHash,omitempty"`
+}
+
+func (m *Metadata) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("metadata without name")
+	}
+	if m.SourceHash != "" {
+		if _, err := decodeHex(m.SourceHash); err != nil {
+			return fmt.Errorf("invalid source hash: %w", err)
+		}
+	}
+	if m.ABI != nil {
+		return m.ABI.Validate()
+	}
+	return nil
+}
+
+// EmbedMetadata returns a copy of the code carrying the metadata, an existing metadata section is replaced.
+// The code hash changes, so metadata should be embedded before the contract is deployed.
+func EmbedMetadata(code []byte, metadata *Metadata) ([]byte, error) {
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	stripped, err := wasm.RemoveCustomSections(code, MetadataSection)
+	if err != nil {
+		return nil, err
+	}
+	return wasm.AppendCustomSection(stripped, MetadataSection, data), nil
+}
+
+// ExtractMetadata returns ErrNoMetadata if the code has no metadata section.
+func ExtractMetadata(code []byte) (*Metadata, error) {
+	m, err := wasm.Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := m.CustomSection(MetadataSection)
+	if !ok {
+		return nil, ErrNoMetadata
+	}
+	return ParseMetadata(data)
+}
+
+func ParseMetadata(data []byte) (*Metadata, error) {
+	res := &Metadata{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("cannot decode metadata: %w", err)
+	}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ContractMetadata extracts metadata from the code of a deployed contract.
+func ContractMetadata(env lib.HostEnv, contract lib.Address) (*Metadata, error) {
+	code := env.GetCode(contract)
+	if len(code) == 0 {
+		return nil, lib.ErrCodeEmpty
+	}
+	return ExtractMetadata(code)
+}
@@ -0,0 +1,196 @@
+// Package args encodes and decodes contract call arguments the same way as the contract SDK does:
+// integers are little-endian, amounts are big-endian unsigned big integers, booleans are a single byte.
+package args
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+)
+
+var (
+	ErrInvalidLength = errors.New("invalid argument length")
+	ErrNoArgument    = errors.New("not enough arguments")
+	ErrNilArgument   = errors.New("argument is nil")
+)
+
+func U8(v uint8) []byte {
+	return []byte{v}
+}
+
+func U16(v uint16) []byte {
+	res := make([]byte, 2)
+	binary.LittleEndian.PutUint16(res, v)
+	return res
+}
+
+func U32(v uint32) []byte {
+	res := make([]byte, 4)
+	binary.LittleEndian.PutUint32(res, v)
+	return res
+}
+
+func U64(v uint64) []byte {
+	res := make([]byte, 8)
+	binary.LittleEndian.PutUint64(res, v)
+	return res
+}
+
+func I64(v int64) []byte {
+	return U64(uint64(v))
+}
+
+func Bool(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func String(v string) []byte {
+	return []byte(v)
+}
+
+func Address(v lib.Address) []byte {
+	return v[:]
+}
+
+// BigInt encodes a non-negative amount, nil is encoded as a nil argument like by Encode.
+func BigInt(v *big.Int) []byte {
+	if v == nil {
+		return nil
+	}
+	return v.Bytes()
+}
+
+func ToU8(data []byte) (uint8, error) {
+	if err := checkLength(data, 1); err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+func ToU16(data []byte) (uint16, error) {
+	if err := checkLength(data, 2); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(data), nil
+}
+
+func ToU32(data []byte) (uint32, error) {
+	if err := checkLength(data, 4); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+func ToU64(data []byte) (uint64, error) {
+	if err := checkLength(data, 8); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+func ToI64(data []byte) (int64, error) {
+	v, err := ToU64(data)
+	return int64(v), err
+}
+
+func ToBool(data []byte) (bool, error) {
+	if err := checkLength(data, 1); err != nil {
+		return false, err
+	}
+	return data[0] != 0, nil
+}
+
+func ToString(data []byte) (string, error) {
+	if data == nil {
+		return "", ErrNilArgument
+	}
+	return string(data), nil
+}
+
+func ToAddress(data []byte) (lib.Address, error) {
+	res := lib.Address{}
+	if err := checkLength(data, len(res)); err != nil {
+		return res, err
+	}
+	copy(res[:], data)
+	return res, nil
+}
+
+func ToBigInt(data []byte) (*big.Int, error) {
+	if data == nil {
+		return nil, ErrNilArgument
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+func checkLength(data []byte, length int) error {
+	if data == nil {
+		return ErrNilArgument
+	}
+	if len(data) != length {
+		return fmt.Errorf("%w: expected %v bytes, got %v", ErrInvalidLength, length, len(data))
+	}
+	return nil
+}
+
+// Encode encodes a Go value of a supported type, nil is encoded as a nil argument.
+func Encode(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case uint8:
+		return U8(v), nil
+	case uint16:
+		return U16(v), nil
+	case uint32:
+		return U32(v), nil
+	case uint64:
+		return U64(v), nil
+	case int64:
+		return I64(v), nil
+	case bool:
+		return Bool(v), nil
+	case string:
+		return String(v), nil
+	case lib.Address:
+		return Address(v), nil
+	case *big.Int:
+		if v == nil {
+			return nil, nil
+		}
+		if v.Sign() < 0 {
+			return nil, errors.New("negative amount")
+		}
+		return BigInt(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported argument type %T", value)
+	}
+}
+
+func EncodeAll(values ...interface{}) ([][]byte, error) {
+	res := make([][]byte, 0, len(values))
+	for idx, value := range values {
+		arg, err := Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %v: %w", idx, err)
+		}
+		res = append(res, arg)
+	}
+	return res, nil
+}
+
+// Pack encodes values and packs them in lib.ArgsProtobufFormat.
+func Pack(values ...interface{}) ([]byte, error) {
+	res, err := EncodeAll(values...)
+	if err != nil {
+		return nil, err
+	}
+	return lib.PackArguments(res), nil
+}
@@ -0,0 +1,252 @@
+package args
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	models "github.com/idena-network/idena-wasm-binding/lib/protobuf"
+	"math/big"
+)
+
+// Builder collects encoded arguments in order.
+type Builder struct {
+	args [][]byte
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) U8(v uint8) *Builder {
+	return b.Raw(U8(v))
+}
+
+func (b *Builder) U16(v uint16) *Builder {
+	return b.Raw(U16(v))
+}
+
+func (b *Builder) U32(v uint32) *Builder {
+	return b.Raw(U32(v))
+}
+
+func (b *Builder) U64(v uint64) *Builder {
+	return b.Raw(U64(v))
+}
+
+func (b *Builder) I64(v int64) *Builder {
+	return b.Raw(I64(v))
+}
+
+func (b *Builder) Bool(v bool) *Builder {
+	return b.Raw(Bool(v))
+}
+
+func (b *Builder) String(v string) *Builder {
+	return b.Raw(String(v))
+}
+
+func (b *Builder) Address(v lib.Address) *Builder {
+	return b.Raw(Address(v))
+}
+
+func (b *Builder) BigInt(v *big.Int) *Builder {
+	return b.Raw(BigInt(v))
+}
+
+func (b *Builder) Raw(v []byte) *Builder {
+	b.args = append(b.args, v)
+	return b
+}
+
+func (b *Builder) Nil() *Builder {
+	return b.Raw(nil)
+}
+
+func (b *Builder) Args() [][]byte {
+	return b.args
+}
+
+func (b *Builder) Pack() []byte {
+	return lib.PackArguments(b.args)
+}
+
+// Decoder reads arguments in order. The first error is kept and returned by Err, subsequent reads return zero values.
+type Decoder struct {
+	args [][]byte
+	pos  int
+	err  error
+}
+
+func NewDecoder(args [][]byte) *Decoder {
+	return &Decoder{args: args}
+}
+
+// Unpack creates a decoder for arguments packed with lib.PackArguments.
+// Unlike lib.UnpackArguments it keeps present empty arguments distinguishable from nil ones.
+func Unpack(data []byte) *Decoder {
+	if len(data) == 0 || data[0] != lib.ArgsProtobufFormat {
+		return NewDecoder(lib.UnpackArguments(data))
+	}
+	argsProto := models.ProtoArgs{}
+	if err := proto.Unmarshal(data[1:], &argsProto); err != nil {
+		return NewDecoder([][]byte{})
+	}
+	res := make([][]byte, 0, len(argsProto.GetArgs()))
+	for _, arg := range argsProto.GetArgs() {
+		switch {
+		case arg.IsNil:
+			res = append(res, nil)
+		case arg.Value == nil:
+			// protobuf decodes empty bytes as nil
+			res = append(res, []byte{})
+		default:
+			res = append(res, arg.Value)
+		}
+	}
+	return NewDecoder(res)
+}
+
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+func (d *Decoder) Len() int {
+	return len(d.args)
+}
+
+// Remaining returns the number of arguments not read yet.
+func (d *Decoder) Remaining() int {
+	return len(d.args) - d.pos
+}
+
+func (d *Decoder) next() ([]byte, bool) {
+	if d.err != nil {
+		return nil, false
+	}
+	if d.pos >= len(d.args) {
+		d.err = ErrNoArgument
+		return nil, false
+	}
+	arg := d.args[d.pos]
+	d.pos++
+	return arg, true
+}
+
+func (d *Decoder) fail(err error) {
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("argument %v: %w", d.pos-1, err)
+	}
+}
+
+func (d *Decoder) U8() uint8 {
+	arg, ok := d.next()
+	if !ok {
+		return 0
+	}
+	v, err := ToU8(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) U16() uint16 {
+	arg, ok := d.next()
+	if !ok {
+		return 0
+	}
+	v, err := ToU16(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) U32() uint32 {
+	arg, ok := d.next()
+	if !ok {
+		return 0
+	}
+	v, err := ToU32(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) U64() uint64 {
+	arg, ok := d.next()
+	if !ok {
+		return 0
+	}
+	v, err := ToU64(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) I64() int64 {
+	arg, ok := d.next()
+	if !ok {
+		return 0
+	}
+	v, err := ToI64(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) Bool() bool {
+	arg, ok := d.next()
+	if !ok {
+		return false
+	}
+	v, err := ToBool(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) String() string {
+	arg, ok := d.next()
+	if !ok {
+		return ""
+	}
+	v, err := ToString(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) Address() lib.Address {
+	arg, ok := d.next()
+	if !ok {
+		return lib.Address{}
+	}
+	v, err := ToAddress(arg)
+	d.fail(err)
+	return v
+}
+
+func (d *Decoder) BigInt() *big.Int {
+	arg, ok := d.next()
+	if !ok {
+		return nil
+	}
+	v, err := ToBigInt(arg)
+	d.fail(err)
+	return v
+}
+
+// Bytes returns the next argument, a nil argument fails the decoder, see Raw for nullable arguments.
+func (d *Decoder) Bytes() []byte {
+	arg, ok := d.next()
+	if !ok {
+		return nil
+	}
+	if arg == nil {
+		d.fail(ErrNilArgument)
+	}
+	return arg
+}
+
+// Raw returns the next argument as is, nil arguments are returned as nil.
+func (d *Decoder) Raw() []byte {
+	arg, _ := d.next()
+	return arg
+}
+
+// IsNil reports whether the next argument is nil without reading it.
+func (d *Decoder) IsNil() bool {
+	return d.err == nil && d.pos < len(d.args) && d.args[d.pos] == nil
+}
@@ -0,0 +1,139 @@
+package callgraph
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"strings"
+)
+
+// Node is a call graph node built from an ExecutionResult node, nodes are numbered in depth-first order.
+type Node struct {
+	ID       int     `json:"id"`
+	Type     string  `json:"type"`
+	Contract string  `json:"contract"`
+	Method   string  `json:"method,omitempty"`
+	GasLimit uint64  `json:"gasLimit"`
+	GasUsed  uint64  `json:"gasUsed"`
+	Amount   string  `json:"amount"`
+	Success  bool    `json:"success"`
+	Error    string  `json:"error,omitempty"`
+	Calls    []*Node `json:"calls,omitempty"`
+}
+
+func Build(result *lib.ExecutionResult) *Node {
+	id := 0
+	return build(result, &id)
+}
+
+func build(result *lib.ExecutionResult, id *int) *Node {
+	node := &Node{
+		ID:       *id,
+		Type:     "unknown",
+		Contract: "0x" + hex.EncodeToString(result.Contract[:]),
+		GasUsed:  result.GasUsed,
+		Amount:   "0",
+		Success:  result.Success,
+		Error:    result.Error,
+	}
+	*id++
+	if action := result.Action; action != nil {
+		node.Type = action.Type.String()
+		node.Method = action.Method
+		node.GasLimit = action.GasLimit
+		if action.Amount != nil {
+			node.Amount = action.Amount.String()
+		}
+	}
+	for _, sub := range result.SubResults {
+		node.Calls = append(node.Calls, build(sub, id))
+	}
+	return node
+}
+
+func (n *Node) walk(fn func(node *Node, parent *Node)) {
+	var walk func(node *Node, parent *Node)
+	walk = func(node *Node, parent *Node) {
+		fn(node, parent)
+		for _, call := range node.Calls {
+			walk(call, node)
+		}
+	}
+	walk(n, nil)
+}
+
+func (n *Node) labelLines() []string {
+	title := n.Type
+	if n.Method != "" {
+		title += " " + n.Method
+	}
+	lines := []string{
+		n.Contract,
+		title,
+		fmt.Sprintf("gas %v / %v", n.GasUsed, n.GasLimit),
+	}
+	if n.Amount != "0" {
+		lines = append(lines, "amount "+n.Amount)
+	}
+	if !n.Success {
+		status := "failed"
+		if n.Error != "" {
+			status += ": " + n.Error
+		}
+		lines = append(lines, status)
+	}
+	return lines
+}
+
+func JSON(result *lib.ExecutionResult) ([]byte, error) {
+	return json.MarshalIndent(Build(result), "", "  ")
+}
+
+// DOT renders the call graph in the Graphviz format, failed nodes are red.
+func DOT(result *lib.ExecutionResult) string {
+	sb := &strings.Builder{}
+	sb.WriteString("digraph calls {\n")
+	sb.WriteString("  node [shape=box];\n")
+	Build(result).walk(func(node *Node, parent *Node) {
+		lines := node.labelLines()
+		for i := range lines {
+			lines[i] = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(lines[i])
+		}
+		color := "black"
+		if !node.Success {
+			color = "red"
+		}
+		fmt.Fprintf(sb, "  n%v [label=\"%v\", color=%v];\n", node.ID, strings.Join(lines, `\n`), color)
+		if parent != nil {
+			fmt.Fprintf(sb, "  n%v -> n%v;\n", parent.ID, node.ID)
+		}
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the call graph as a Mermaid flowchart, failed nodes have the failed class.
+func Mermaid(result *lib.ExecutionResult) string {
+	sb := &strings.Builder{}
+	sb.WriteString("graph TD\n")
+	var failed []string
+	Build(result).walk(func(node *Node, parent *Node) {
+		lines := node.labelLines()
+		for i := range lines {
+			lines[i] = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ").Replace(lines[i])
+		}
+		fmt.Fprintf(sb, "  n%v[\"%v\"]\n", node.ID, strings.Join(lines, "<br/>"))
+		if parent != nil {
+			fmt.Fprintf(sb, "  n%v --> n%v\n", parent.ID, node.ID)
+		}
+		if !node.Success {
+			failed = append(failed, fmt.Sprintf("n%v", node.ID))
+		}
+	})
+	if len(failed) > 0 {
+		sb.WriteString("  classDef failed stroke:#d00,color:#d00\n")
+		fmt.Fprintf(sb, "  class %v failed\n", strings.Join(failed, ","))
+	}
+	return sb.String()
+}
@@ -0,0 +1,49 @@
+// Command abigen generates a typed Go client from a contract ABI description.
+//
+//	//go:generate go run github.com/idena-network/idena-wasm-binding/cmd/abigen -abi token.json -pkg token -out token_client.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"github.com/idena-network/idena-wasm-binding/abi/gen"
+	"os"
+)
+
+func main() {
+	abiPath := flag.String("abi", "", "path to the contract ABI json")
+	pkg := flag.String("pkg", "", "package name of the generated file, defaults to $GOPACKAGE")
+	typeName := flag.String("type", "", "name of the generated client type, defaults to the ABI name")
+	out := flag.String("out", "", "output file, stdout is used if empty")
+	flag.Parse()
+
+	if *abiPath == "" {
+		fmt.Fprintln(os.Stderr, "abi path is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pkg == "" {
+		*pkg = os.Getenv("GOPACKAGE")
+	}
+	if err := run(*abiPath, *pkg, *typeName, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(abiPath, pkg, typeName, out string) error {
+	contractABI, err := abi.Load(abiPath)
+	if err != nil {
+		return err
+	}
+	code, err := gen.Generate(contractABI, gen.Options{Package: pkg, Type: typeName})
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0644)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"github.com/idena-network/idena-wasm-binding/args"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// argList is a repeatable flag of comma-separated contract arguments.
+type argList []string
+
+func (l *argList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *argList) Set(value string) error {
+	*l = append(*l, strings.Split(value, ",")...)
+	return nil
+}
+
+// argValue is a repeatable flag of a single contract argument, the value is added to the list as is,
+// so it may contain commas.
+type argValue struct {
+	list *argList
+}
+
+func (v argValue) String() string {
+	if v.list == nil {
+		return ""
+	}
+	return v.list.String()
+}
+
+func (v argValue) Set(value string) error {
+	*v.list = append(*v.list, value)
+	return nil
+}
+
+func (l *argList) parse() ([][]byte, error) {
+	res := make([][]byte, 0, len(*l))
+	for _, arg := range *l {
+		value, err := parseArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, value)
+	}
+	return res, nil
+}
+
+// parseArg converts a command-line value to a contract argument:
+//
+//	0x0a0b      raw bytes
+//	123         u64
+//	-5          i64
+//	nil         nil argument
+//	type:value  value of an ABI type, e.g. u32:7, amount:1000, bool:true, address:0x..., string:1,2
+//	other       string
+//
+// Values with commas, like string:1,2, have to be passed with -arg rather than -args.
+func parseArg(s string) ([]byte, error) {
+	if s == "nil" {
+		return nil, nil
+	}
+	if strings.HasPrefix(s, "0x") {
+		return decodeHex(s)
+	}
+	if idx := strings.Index(s, ":"); idx > 0 {
+		if t := abi.Type(s[:idx]); t.IsValid() {
+			return parseTyped(t, s[idx+1:])
+		}
+	}
+	if v, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return args.U64(v), nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return args.I64(v), nil
+	}
+	return args.String(s), nil
+}
+
+func parseTyped(t abi.Type, s string) ([]byte, error) {
+	var value interface{} = s
+	switch t {
+	case abi.TypeU8, abi.TypeU16, abi.TypeU32, abi.TypeU64:
+		v, err := strconv.ParseUint(s, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v %q", t, s)
+		}
+		value = v
+	case abi.TypeI64:
+		v, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v %q", t, s)
+		}
+		value = v
+	case abi.TypeBool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v %q", t, s)
+		}
+		value = v
+	}
+	return abi.Encode(t, value)
+}
+
+func parseAddress(s string) (lib.Address, error) {
+	data, err := decodeHex(s)
+	if err != nil {
+		return lib.Address{}, err
+	}
+	return args.ToAddress(data)
+}
+
+func parseAmount(s string) (*big.Int, error) {
+	res, ok := new(big.Int).SetString(s, 10)
+	if !ok || res.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return res, nil
+}
+
+func decodeHex(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("hex value %q must have 0x prefix", s)
+	}
+	res, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex value %q: %w", s, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"github.com/idena-network/idena-wasm-binding/args"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/big"
+	"testing"
+)
+
+func TestParseArg(t *testing.T) {
+	cases := []struct {
+		arg      string
+		expected []byte
+	}{
+		{"nil", nil},
+		{"0x0a0b", []byte{0xa, 0xb}},
+		{"123", args.U64(123)},
+		{"-5", args.I64(-5)},
+		{"u32:7", args.U32(7)},
+		{"u8:0x10", args.U8(16)},
+		{"i64:-7", args.I64(-7)},
+		{"bool:true", args.Bool(true)},
+		{"amount:1000", args.BigInt(big.NewInt(1000))},
+		{"address:0x0200000000000000000000000000000000000000", args.Address(lib.Address{0x2})},
+		{"string:1,2", args.String("1,2")},
+		{"string:", args.String("")},
+		{"hello", args.String("hello")},
+		{"foo:bar", args.String("foo:bar")},
+	}
+	for _, c := range cases {
+		res, err := parseArg(c.arg)
+		require.NoError(t, err, c.arg)
+		require.Equal(t, c.expected, res, c.arg)
+	}
+
+	for _, arg := range []string{"0x0", "0xzz", "u8:256", "u32:-1", "i64:x", "bool:yes", "amount:-1", "address:0x01"} {
+		_, err := parseArg(arg)
+		require.Error(t, err, arg)
+	}
+}
+
+func TestArgFlags(t *testing.T) {
+	fs, f := newTxFlagSet("call")
+	require.NoError(t, fs.Parse([]string{"-args", "1,u8:2", "-arg", "string:a,b", "-args", "nil"}))
+	require.Equal(t, argList{"1", "u8:2", "string:a,b", "nil"}, f.args)
+
+	res, err := f.args.parse()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{args.U64(1), args.U8(2), args.String("a,b"), nil}, res)
+
+	f.args = argList{"u8:x"}
+	_, err = f.args.parse()
+	require.Error(t, err)
+}
+
+func TestParseFlags(t *testing.T) {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	gas := fs.Uint64("gas", 0, "")
+	amount := fs.String("amount", "", "")
+
+	positional, err := parseFlags(fs, []string{"-gas", "10", "0x01", "-amount", "5", "inc"}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0x01", "inc"}, positional)
+	require.Equal(t, uint64(10), *gas)
+	require.Equal(t, "5", *amount)
+
+	positional, err = parseFlags(fs, []string{"0x01", "inc", "-gas", "20"}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0x01", "inc"}, positional)
+	require.Equal(t, uint64(20), *gas)
+
+	_, err = parseFlags(fs, []string{"0x01", "-gas", "10"}, 2)
+	require.EqualError(t, err, "call: expected 2 arguments, got 1")
+
+	_, err = parseFlags(fs, []string{"0x01", "inc", "dec"}, 2)
+	require.EqualError(t, err, "call: expected 2 arguments, got 3")
+
+	fs.SetOutput(io.Discard)
+	_, err = parseFlags(fs, []string{"0x01", "-unknown", "inc"}, 2)
+	require.Error(t, err)
+}
@@ -0,0 +1,439 @@
+// Command idena-wasm deploys and calls contracts against a host state kept in a JSON file.
+//
+// Usage:
+//
+//	idena-wasm [-state file] deploy <file.wasm> [-args a,b] [-arg a] [-amount n] [-caller addr] [-gas n]
+//	idena-wasm [-state file] call <addr> <method> [-args a,b] [-arg a] [-amount n] [-caller addr] [-gas n]
+//	idena-wasm [-state file] read <addr> <key>
+//	idena-wasm [-state file] events [-contract addr]
+//	idena-wasm [-state file] balance <addr> [-set n]
+//	idena-wasm [-state file] info <file.wasm | addr> [-methods] [-json]
+//	idena-wasm [-state file] lint <file.wasm | addr> [-severity level]
+//	idena-wasm replay <recording.json>
+//
+// The state is kept in a JSON file or, with -db dir, in a goleveldb database.
+// Arguments are parsed by parseArg, amounts are decimal numbers of the smallest units.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/wasm"
+	"github.com/idena-network/idena-wasm-binding/wasminfo"
+	"math/big"
+	"os"
+)
+
+const (
+	defaultStateFile = "idena-wasm-state.json"
+	defaultCaller    = "0x0000000000000000000000000000000000000001"
+	defaultGasLimit  = 10_000_000
+)
+
+type command struct {
+	usage string
+	// needsState is false for commands which run without the host state, they get a nil state
+	needsState bool
+	run        func(state *hostState, args []string) error
+}
+
+var commands = map[string]command{
+	"deploy":  {"deploy <file.wasm> [-args a,b] [-arg a] [-amount n] [-caller addr] [-gas n]", true, deployCmd},
+	"call":    {"call <addr> <method> [-args a,b] [-arg a] [-amount n] [-caller addr] [-gas n]", true, callCmd},
+	"read":    {"read <addr> <key>", true, readCmd},
+	"events":  {"events [-contract addr]", true, eventsCmd},
+	"balance": {"balance <addr> [-set n]", true, balanceCmd},
+	"info":    {"info <file.wasm | addr> [-methods] [-json]", true, infoCmd},
+	"lint":    {"lint <file.wasm | addr> [-severity level]", true, lintCmd},
+	"replay":  {"replay <recording.json>", false, replayCmd},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: idena-wasm [-state file | -db dir] <command>\n\ncommands:\n")
+	for _, name := range []string{"deploy", "call", "read", "events", "balance", "info", "lint", "replay"} {
+		fmt.Fprintf(os.Stderr, "  %v\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	statePath := flag.String("state", defaultStateFile, "host state JSON file, created if missing")
+	dbPath := flag.String("db", "", "goleveldb directory keeping the host state instead of the state file")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if !cmd.needsState {
+		if err := cmd.run(nil, flag.Args()[1:]); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	var state *hostState
+	var err error
+	if *dbPath != "" {
+		state, err = loadDbState(*dbPath)
+	} else {
+		state, err = loadFileState(*statePath)
+	}
+	if err != nil {
+		fatal(fmt.Errorf("cannot load state: %w", err))
+	}
+	err = cmd.run(state, flag.Args()[1:])
+	if closeErr := state.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// errReported is returned by commands which have already printed the failure.
+var errReported = errors.New("reported")
+
+func fatal(err error) {
+	if err == errReported {
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	os.Exit(1)
+}
+
+// parseFlags parses flags placed anywhere among the positional arguments and returns the positional ones.
+func parseFlags(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	var res []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		res = append(res, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(res) != positional {
+		return nil, fmt.Errorf("%v: expected %v arguments, got %v", fs.Name(), positional, len(res))
+	}
+	return res, nil
+}
+
+type txFlags struct {
+	args        argList
+	amount      string
+	caller      string
+	gas         uint64
+	gasSchedule string
+	forks       string
+	record      string
+}
+
+func newTxFlagSet(name string) (*flag.FlagSet, *txFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := &txFlags{}
+	fs.Var(&f.args, "args", "comma-separated contract arguments, may be repeated")
+	fs.Var(argValue{&f.args}, "arg", "single contract argument which may contain commas, may be repeated")
+	fs.StringVar(&f.amount, "amount", "0", "amount sent to the contract")
+	fs.StringVar(&f.caller, "caller", defaultCaller, "caller address")
+	fs.Uint64Var(&f.gas, "gas", defaultGasLimit, "gas limit")
+	fs.StringVar(&f.gasSchedule, "gas-schedule", "", "gas schedule JSON file the binding charges host functions with")
+	fs.StringVar(&f.forks, "forks", "", "fork config JSON file, it overrides -gas-schedule")
+	fs.StringVar(&f.record, "record", "", "file to record host calls to for the replay command")
+	return fs, f
+}
+
+func (f *txFlags) tx(contract lib.Address, method string, code []byte, isDeploy bool) (tx, error) {
+	caller, err := parseAddress(f.caller)
+	if err != nil {
+		return tx{}, err
+	}
+	amount, err := parseAmount(f.amount)
+	if err != nil {
+		return tx{}, err
+	}
+	args, err := f.args.parse()
+	if err != nil {
+		return tx{}, err
+	}
+	var schedule *lib.GasSchedule
+	if f.gasSchedule != "" {
+		data, err := os.ReadFile(f.gasSchedule)
+		if err != nil {
+			return tx{}, err
+		}
+		if schedule, err = lib.ParseGasSchedule(data); err != nil {
+			return tx{}, err
+		}
+	}
+	var forks *lib.ForkConfig
+	if f.forks != "" {
+		data, err := os.ReadFile(f.forks)
+		if err != nil {
+			return tx{}, err
+		}
+		if forks, err = lib.ParseForkConfig(data); err != nil {
+			return tx{}, err
+		}
+	}
+	return tx{
+		caller:   caller,
+		contract: contract,
+		method:   method,
+		amount:   amount,
+		isDeploy: isDeploy,
+		code:     code,
+		args:     args,
+		gasLimit: f.gas,
+		schedule: schedule,
+		forks:    forks,
+		record:   f.record,
+	}, nil
+}
+
+func deployCmd(state *hostState, args []string) error {
+	fs, f := newTxFlagSet("deploy")
+	positional, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	code, err := os.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+	t, err := f.tx(lib.Address{}, "deploy", code, true)
+	if err != nil {
+		return err
+	}
+	nonce := big.NewInt(int64(state.nonce(t.caller))).Bytes()
+	t.contract = memory.ContractAddress(memory.CodeHash(code), lib.PackArguments(t.args), nonce)
+	if state.store.ContractCodeHash(t.contract) != nil {
+		return lib.ErrAlreadyDeployed
+	}
+	result, err := state.execute(t)
+	return finish(state, result, err)
+}
+
+func callCmd(state *hostState, args []string) error {
+	fs, f := newTxFlagSet("call")
+	positional, err := parseFlags(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	contract, err := parseAddress(positional[0])
+	if err != nil {
+		return err
+	}
+	code := state.code(contract)
+	if len(code) == 0 {
+		return lib.ErrCodeEmpty
+	}
+	t, err := f.tx(contract, positional[1], code, false)
+	if err != nil {
+		return err
+	}
+	result, err := state.execute(t)
+	return finish(state, result, err)
+}
+
+// finish prints the result and saves the state, a failed execution still advances the block and the caller nonce.
+func finish(state *hostState, result *lib.ExecutionResult, err error) error {
+	if result != nil {
+		printResult(os.Stdout, result)
+	}
+	if saveErr := state.save(); saveErr != nil {
+		return saveErr
+	}
+	var executionErr *lib.ExecutionError
+	if errors.As(err, &executionErr) && result != nil {
+		return errReported
+	}
+	return err
+}
+
+func readCmd(state *hostState, args []string) error {
+	fs := flag.NewFlagSet("read", flag.ContinueOnError)
+	positional, err := parseFlags(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	contract, err := parseAddress(positional[0])
+	if err != nil {
+		return err
+	}
+	key, err := parseArg(positional[1])
+	if err != nil {
+		return err
+	}
+	value := state.store.GetStorage(contract, key)
+	if value == nil {
+		return fmt.Errorf("key %v not found", positional[1])
+	}
+	fmt.Println(formatBytes(value))
+	return nil
+}
+
+func eventsCmd(state *hostState, args []string) error {
+	fs := flag.NewFlagSet("events", flag.ContinueOnError)
+	contract := fs.String("contract", "", "show events of the contract only")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	var filter *lib.Address
+	if *contract != "" {
+		addr, err := parseAddress(*contract)
+		if err != nil {
+			return err
+		}
+		filter = &addr
+	}
+	printEvents(os.Stdout, state.Events, filter)
+	return nil
+}
+
+func balanceCmd(state *hostState, args []string) error {
+	fs := flag.NewFlagSet("balance", flag.ContinueOnError)
+	set := fs.String("set", "", "set the balance")
+	positional, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	addr, err := parseAddress(positional[0])
+	if err != nil {
+		return err
+	}
+	if *set != "" {
+		balance, err := parseAmount(*set)
+		if err != nil {
+			return err
+		}
+		state.store.SetBalance(addr, balance)
+		if err := state.save(); err != nil {
+			return err
+		}
+	}
+	fmt.Println(state.store.Balance(addr))
+	return nil
+}
+
+func infoCmd(state *hostState, args []string) error {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	methods := fs.Bool("methods", false, "print method names only, one per line")
+	asJson := fs.Bool("json", false, "print info in JSON")
+	positional, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	code, err := readCode(state, positional[0])
+	if err != nil {
+		return err
+	}
+	info, err := wasminfo.Inspect(code)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *methods:
+		for _, method := range info.Methods() {
+			fmt.Println(method)
+		}
+	case *asJson:
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		printInfo(os.Stdout, info)
+	}
+	return nil
+}
+
+// readCode reads code of a deployed contract if source is an address and a file otherwise.
+func readCode(state *hostState, source string) ([]byte, error) {
+	contract, err := parseAddress(source)
+	if err != nil {
+		return os.ReadFile(source)
+	}
+	code := state.code(contract)
+	if len(code) == 0 {
+		return nil, lib.ErrCodeEmpty
+	}
+	return code, nil
+}
+
+// lintCmd prints findings and fails if any of them is an error.
+func lintCmd(state *hostState, args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	severity := fs.String("severity", string(wasm.SeverityInfo), "minimal severity to print: info, warning or error")
+	positional, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	min := wasm.Severity(*severity)
+	if min != wasm.SeverityInfo && min != wasm.SeverityWarning && min != wasm.SeverityError {
+		return fmt.Errorf("unknown severity %q", *severity)
+	}
+	code, err := readCode(state, positional[0])
+	if err != nil {
+		return err
+	}
+	report, err := wasm.Lint(code, nil)
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, finding := range report.Filter(min) {
+		fmt.Println(finding)
+		failed = failed || finding.Severity == wasm.SeverityError
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+// replayCmd re-executes a recording made with -record, it runs without the host state
+// and charges the gas schedule and the fork config of the recording.
+func replayCmd(_ *hostState, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	positional, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+	recording, err := hostenv.ParseRecording(data)
+	if err != nil {
+		return err
+	}
+	env := hostenv.NewReplayHostEnv(recording)
+	result, err := recording.Run(recording.NewGoAPI(env, &lib.GasMeter{}))
+	if result != nil {
+		printResult(os.Stdout, result)
+	}
+	if verifyErr := env.Verify(); verifyErr != nil {
+		return verifyErr
+	}
+	fmt.Printf("replayed %v host calls\n", len(recording.Calls))
+	var executionErr *lib.ExecutionError
+	if errors.As(err, &executionErr) && result != nil {
+		return errReported
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/callgraph"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/wasminfo"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func printResult(w io.Writer, result *lib.ExecutionResult) {
+	status := "success"
+	if !result.Success {
+		status = "failed"
+		if result.Error != "" {
+			status += ": " + result.Error
+		}
+	}
+	fmt.Fprintf(w, "status:    %v\n", status)
+	fmt.Fprintf(w, "contract:  0x%x\n", result.Contract[:])
+	fmt.Fprintf(w, "gas used:  %v\n", result.GasUsed)
+	if len(result.OutputData) > 0 {
+		fmt.Fprintf(w, "output:    %v\n", formatBytes(result.OutputData))
+	}
+	if len(result.SubResults) > 0 {
+		fmt.Fprintf(w, "calls:\n")
+		for _, call := range callgraph.Build(result).Calls {
+			printNode(w, call, 1)
+		}
+	}
+}
+
+func printNode(w io.Writer, node *callgraph.Node, depth int) {
+	line := node.Type
+	if node.Method != "" {
+		line += " " + node.Method
+	}
+	line += fmt.Sprintf(" %v gas %v/%v", node.Contract, node.GasUsed, node.GasLimit)
+	if node.Amount != "0" {
+		line += " amount " + node.Amount
+	}
+	if !node.Success {
+		line += " failed"
+		if node.Error != "" {
+			line += ": " + node.Error
+		}
+	}
+	fmt.Fprintf(w, "%v%v\n", strings.Repeat("  ", depth), line)
+	for _, call := range node.Calls {
+		printNode(w, call, depth+1)
+	}
+}
+
+func printEvents(w io.Writer, events []fileEvent, contract *lib.Address) {
+	for i, e := range events {
+		if contract != nil && e.Contract != "0x"+hex.EncodeToString(contract[:]) {
+			continue
+		}
+		fmt.Fprintf(w, "#%v block %v %v %v", i, e.Block, e.Contract, e.Name)
+		for _, arg := range e.Args {
+			data, err := decodeHex(arg)
+			if err != nil {
+				fmt.Fprintf(w, " %v", arg)
+				continue
+			}
+			fmt.Fprintf(w, " %v", formatBytes(data))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// formatBytes prints data in hex followed by the quoted string if data is printable text.
+func formatBytes(data []byte) string {
+	res := "0x" + hex.EncodeToString(data)
+	if isPrintable(data) {
+		res += " " + strconv.Quote(string(data))
+	}
+	return res
+}
+
+func isPrintable(data []byte) bool {
+	if len(data) == 0 || !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func printInfo(w io.Writer, info *wasminfo.Info) {
+	fmt.Fprintf(w, "code hash: %v\n", info.CodeHash)
+	fmt.Fprintf(w, "size:      %v\n", info.Size)
+	if m := info.Metadata; m != nil {
+		fmt.Fprintf(w, "contract:  %v %v\n", m.Name, m.Version)
+		if m.SourceHash != "" {
+			fmt.Fprintf(w, "source:    %v\n", m.SourceHash)
+		}
+	}
+	if info.MetadataError != "" {
+		fmt.Fprintf(w, "metadata:  invalid: %v\n", info.MetadataError)
+	}
+	for _, m := range info.Memories {
+		max := "none"
+		if m.Max != nil {
+			max = strconv.FormatUint(uint64(*m.Max), 10)
+		}
+		fmt.Fprintf(w, "memory:    %v pages, max %v\n", m.Min, max)
+	}
+	fmt.Fprintf(w, "exports:\n")
+	for _, e := range info.Exports {
+		if e.Signature != nil {
+			fmt.Fprintf(w, "  %v%v\n", e.Name, e.Signature)
+		}
+	}
+	fmt.Fprintf(w, "host functions:\n")
+	for _, imp := range info.Imports {
+		if imp.Signature != nil {
+			fmt.Fprintf(w, "  %v.%v%v\n", imp.Module, imp.Name, imp.Signature)
+		}
+	}
+	if len(info.CustomSections) > 0 {
+		fmt.Fprintf(w, "custom sections:\n")
+		for _, s := range info.CustomSections {
+			fmt.Fprintf(w, "  %v (%v bytes)\n", s.Name, s.Size)
+		}
+	}
+}
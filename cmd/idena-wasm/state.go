@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/dbstore"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	db "github.com/tendermint/tm-db"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+const (
+	blockInterval = 20
+	epochBlocks   = 1000
+
+	// chainMetaKey is the dbstore meta key of the chain data
+	chainMetaKey = "idena-wasm"
+)
+
+type fileEvent struct {
+	Block    uint64   `json:"block"`
+	Contract string   `json:"contract"`
+	Name     string   `json:"name"`
+	Args     []string `json:"args"`
+}
+
+// chainData is the chain data kept beside the contract state, every transaction is executed in a new block.
+type chainData struct {
+	BlockNumber uint64            `json:"blockNumber"`
+	Nonces      map[string]uint64 `json:"nonces"`
+	Events      []fileEvent       `json:"events"`
+}
+
+type stateFile struct {
+	State *memory.State `json:"state"`
+	chainData
+}
+
+// hostState is the host state kept between runs either in a JSON file or in a goleveldb database.
+type hostState struct {
+	store memory.Store
+	chainData
+
+	save  func() error
+	close func() error
+}
+
+func newChainData() chainData {
+	return chainData{
+		BlockNumber: 1,
+		Nonces:      map[string]uint64{},
+	}
+}
+
+func loadFileState(path string) (*hostState, error) {
+	file := stateFile{
+		State:     memory.NewState(),
+		chainData: newChainData(),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+	}
+	s := &hostState{
+		store: file.State,
+		close: func() error { return nil },
+	}
+	s.chainData = file.chainData
+	s.save = func() error {
+		file.chainData = s.chainData
+		data, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0644)
+	}
+	s.initNonces()
+	return s, nil
+}
+
+func loadDbState(dir string) (*hostState, error) {
+	database, err := db.NewDB(filepath.Base(dir), db.GoLevelDBBackend, filepath.Dir(dir))
+	if err != nil {
+		return nil, err
+	}
+	store := dbstore.NewStore(database)
+	s := &hostState{
+		store:     store,
+		chainData: newChainData(),
+		close:     database.Close,
+	}
+	if data := store.Meta(chainMetaKey); data != nil {
+		if err := json.Unmarshal(data, &s.chainData); err != nil {
+			database.Close()
+			return nil, err
+		}
+	}
+	s.save = func() error {
+		data, err := json.Marshal(s.chainData)
+		if err != nil {
+			return err
+		}
+		store.SetMeta(chainMetaKey, data)
+		return store.Flush()
+	}
+	s.initNonces()
+	return s, nil
+}
+
+func (s *hostState) initNonces() {
+	if s.Nonces == nil {
+		s.Nonces = map[string]uint64{}
+	}
+}
+
+func (s *hostState) block() *memory.Block {
+	return &memory.Block{
+		Number:       s.BlockNumber,
+		Timestamp:    int64(s.BlockNumber * blockInterval),
+		MinFeePerGas: big.NewInt(0),
+		NetworkSize:  1,
+		Epoch:        uint16(s.BlockNumber / epochBlocks),
+		Headers:      map[uint64][]byte{},
+	}
+}
+
+func (s *hostState) nonce(addr lib.Address) uint64 {
+	return s.Nonces[hex.EncodeToString(addr[:])]
+}
+
+func (s *hostState) code(contract lib.Address) []byte {
+	hash := s.store.ContractCodeHash(contract)
+	if hash == nil {
+		return nil
+	}
+	return s.store.Code(hash)
+}
+
+type tx struct {
+	caller   lib.Address
+	contract lib.Address
+	method   string
+	amount   *big.Int
+	isDeploy bool
+	code     []byte
+	args     [][]byte
+	gasLimit uint64
+	schedule *lib.GasSchedule
+	forks    *lib.ForkConfig
+	// record is the file the host env calls are recorded to if not empty
+	record string
+}
+
+func (t tx) execution() *hostenv.RecordedExecution {
+	res := &hostenv.RecordedExecution{
+		Code:     t.code,
+		Contract: t.contract[:],
+		GasLimit: t.gasLimit,
+		IsDeploy: t.isDeploy,
+	}
+	if !t.isDeploy {
+		res.Method = t.method
+	}
+	for _, arg := range t.args {
+		res.Args = append(res.Args, arg)
+	}
+	return res
+}
+
+// execute runs the transaction, the env flushes its changes to a journal over the store which is committed
+// only if the execution succeeds.
+func (s *hostState) execute(t tx) (result *lib.ExecutionResult, err error) {
+	defer func() {
+		s.Nonces[hex.EncodeToString(t.caller[:])]++
+		s.BlockNumber++
+	}()
+	block := s.block()
+	journal := hostenv.NewJournal(s.store)
+	env := memory.NewEnv(journal, block, memory.Context{
+		Caller:         t.caller,
+		OriginalCaller: t.caller,
+		Contract:       t.contract,
+		Method:         t.method,
+		PayAmount:      t.amount,
+		IsDeploy:       t.isDeploy,
+	})
+	if err := env.Transfer(t.caller, t.contract, t.amount); err != nil {
+		return nil, err
+	}
+	if t.isDeploy {
+		env.Deploy(t.code)
+	}
+	var host lib.HostEnv = env
+	recording := &hostenv.Recording{}
+	if t.record != "" {
+		recordingEnv := hostenv.NewRecordingHostEnv(env)
+		host, recording = recordingEnv, recordingEnv.Recording()
+		defer func() {
+			if writeErr := writeRecording(t.record, recording); err == nil {
+				err = writeErr
+			}
+		}()
+	}
+	recording.GasSchedule, recording.Forks = t.schedule, t.forks
+	recording.Execution = t.execution()
+	result, err = recording.Run(recording.NewGoAPI(host, &lib.GasMeter{}))
+	if err != nil {
+		return result, err
+	}
+	env.Commit()
+	journal.Commit()
+	for _, e := range env.Events() {
+		event := fileEvent{
+			Block:    block.Number,
+			Contract: "0x" + hex.EncodeToString(e.Contract[:]),
+			Name:     e.Name,
+		}
+		for _, arg := range e.Args {
+			event.Args = append(event.Args, "0x"+hex.EncodeToString(arg))
+		}
+		s.Events = append(s.Events, event)
+	}
+	return result, nil
+}
+
+func writeRecording(path string, recording *hostenv.Recording) error {
+	data, err := recording.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	loaders := map[string]func() (*hostState, error){
+		"file": func() (*hostState, error) { return loadFileState(filepath.Join(dir, "state.json")) },
+		"db":   func() (*hostState, error) { return loadDbState(filepath.Join(dir, "state.db")) },
+	}
+	for name, load := range loaders {
+		t.Run(name, func(t *testing.T) {
+			state, err := load()
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), state.BlockNumber)
+
+			caller, contract := lib.Address{0x1}, lib.Address{0x2}
+			state.store.SetBalance(caller, big.NewInt(100))
+			state.store.SetCode([]byte{0x3}, []byte{0x0, 0x61, 0x73, 0x6d})
+			state.store.SetContractCodeHash(contract, []byte{0x3})
+			state.store.SetStorage(contract, []byte("k"), []byte("v"))
+			state.BlockNumber = 5
+			state.Nonces["0100000000000000000000000000000000000000"] = 2
+			state.Events = append(state.Events, fileEvent{Block: 4, Contract: "0x02", Name: "inc", Args: []string{"0x01"}})
+			require.NoError(t, state.save())
+			require.NoError(t, state.close())
+
+			state, err = load()
+			require.NoError(t, err)
+			defer state.close()
+			require.Equal(t, big.NewInt(100), state.store.Balance(caller))
+			require.Equal(t, []byte{0x0, 0x61, 0x73, 0x6d}, state.code(contract))
+			require.Equal(t, []byte("v"), state.store.GetStorage(contract, []byte("k")))
+			require.Equal(t, uint64(5), state.BlockNumber)
+			require.Equal(t, uint64(2), state.nonce(caller))
+			require.Equal(t, []fileEvent{{Block: 4, Contract: "0x02", Name: "inc", Args: []string{"0x01"}}}, state.Events)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package dbstore
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	db "github.com/tendermint/tm-db"
+	"math/big"
+)
+
+// Key prefixes of the store, contract storage uses the node layout 0x5 ++ contract ++ key.
+const (
+	StoragePrefix  byte = 0x5
+	BalancePrefix  byte = 0x6
+	CodeHashPrefix byte = 0x7
+	CodePrefix     byte = 0x8
+	IdentityPrefix byte = 0x9
+	MetaPrefix     byte = 0xa
+)
+
+func StorageKey(contract lib.Address, key []byte) []byte {
+	return dbKey(StoragePrefix, contract[:], key)
+}
+
+func dbKey(prefix byte, id []byte, key []byte) []byte {
+	res := make([]byte, 0, 1+len(id)+len(key))
+	res = append(res, prefix)
+	res = append(res, id...)
+	return append(res, key...)
+}
+
+// Store is a memory.Store persisted in a tm-db database, so memory.NewEnv over it is a persistent HostEnv.
+// Writes are kept in memory until Flush writes them to the database atomically, usually right after
+// the root env is committed. The Store interface has no errors, database read errors cause panics
+// which the binding turns into failed executions when raised by host callbacks.
+type Store struct {
+	db db.DB
+	// pending holds unflushed writes, nil value removes the key
+	pending map[string][]byte
+}
+
+func NewStore(db db.DB) *Store {
+	return &Store{
+		db:      db,
+		pending: map[string][]byte{},
+	}
+}
+
+func (s *Store) get(key []byte) []byte {
+	if value, ok := s.pending[string(key)]; ok {
+		return value
+	}
+	value, err := s.db.Get(key)
+	if err != nil {
+		panic(fmt.Errorf("cannot read key %x: %w", key, err))
+	}
+	return value
+}
+
+func (s *Store) set(key []byte, value []byte) {
+	if value == nil {
+		s.pending[string(key)] = nil
+		return
+	}
+	s.pending[string(key)] = append([]byte{}, value...)
+}
+
+// Flush writes pending changes to the database in a single batch.
+func (s *Store) Flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	batch := s.db.NewBatch()
+	defer batch.Close()
+	for key, value := range s.pending {
+		var err error
+		if value == nil {
+			err = batch.Delete([]byte(key))
+		} else {
+			err = batch.Set([]byte(key), value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := batch.WriteSync(); err != nil {
+		return err
+	}
+	s.pending = map[string][]byte{}
+	return nil
+}
+
+// Discard drops pending changes.
+func (s *Store) Discard() {
+	s.pending = map[string][]byte{}
+}
+
+func (s *Store) GetStorage(contract lib.Address, key []byte) []byte {
+	return s.get(StorageKey(contract, key))
+}
+
+func (s *Store) SetStorage(contract lib.Address, key []byte, value []byte) {
+	s.set(StorageKey(contract, key), value)
+}
+
+// StorageKeys returns all keys stored by the contract including pending ones.
+func (s *Store) StorageKeys(contract lib.Address) ([][]byte, error) {
+	prefix := dbKey(StoragePrefix, contract[:], nil)
+	var keys [][]byte
+	err := s.iterate(prefix, func(key []byte) {
+		keys = append(keys, key[len(prefix):])
+	})
+	return keys, err
+}
+
+func (s *Store) Balance(addr lib.Address) *big.Int {
+	return new(big.Int).SetBytes(s.get(dbKey(BalancePrefix, addr[:], nil)))
+}
+
+func (s *Store) SetBalance(addr lib.Address, balance *big.Int) {
+	if balance.Sign() == 0 {
+		s.set(dbKey(BalancePrefix, addr[:], nil), nil)
+		return
+	}
+	s.set(dbKey(BalancePrefix, addr[:], nil), balance.Bytes())
+}
+
+func (s *Store) ContractCodeHash(contract lib.Address) []byte {
+	return s.get(dbKey(CodeHashPrefix, contract[:], nil))
+}
+
+func (s *Store) SetContractCodeHash(contract lib.Address, hash []byte) {
+	s.set(dbKey(CodeHashPrefix, contract[:], nil), hash)
+}
+
+// Contracts returns addresses of all deployed contracts including pending ones.
+func (s *Store) Contracts() ([]lib.Address, error) {
+	var contracts []lib.Address
+	err := s.iterate([]byte{CodeHashPrefix}, func(key []byte) {
+		var addr lib.Address
+		copy(addr[:], key[1:])
+		contracts = append(contracts, addr)
+	})
+	return contracts, err
+}
+
+func (s *Store) Code(hash []byte) []byte {
+	return s.get(dbKey(CodePrefix, hash, nil))
+}
+
+func (s *Store) SetCode(hash []byte, code []byte) {
+	s.set(dbKey(CodePrefix, hash, nil), code)
+}
+
+func (s *Store) Identity(addr lib.Address) []byte {
+	return s.get(dbKey(IdentityPrefix, addr[:], nil))
+}
+
+func (s *Store) SetIdentity(addr lib.Address, identity []byte) {
+	s.set(dbKey(IdentityPrefix, addr[:], nil), identity)
+}
+
+// Meta returns a value stored by the host beside the contract state, e.g. the last block of a local devnet.
+func (s *Store) Meta(name string) []byte {
+	return s.get(dbKey(MetaPrefix, []byte(name), nil))
+}
+
+func (s *Store) SetMeta(name string, value []byte) {
+	s.set(dbKey(MetaPrefix, []byte(name), nil), value)
+}
+
+// iterate calls fn for every existing key with the prefix, pending changes are taken into account.
+func (s *Store) iterate(prefix []byte, fn func(key []byte)) error {
+	it, err := s.db.Iterator(prefix, prefixEnd(prefix))
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	seen := map[string]struct{}{}
+	for ; it.Valid(); it.Next() {
+		key := it.Key()
+		seen[string(key)] = struct{}{}
+		if value, ok := s.pending[string(key)]; ok && value == nil {
+			continue
+		}
+		fn(append([]byte{}, key...))
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	for key, value := range s.pending {
+		if _, ok := seen[key]; ok || value == nil || len(key) < len(prefix) || key[:len(prefix)] != string(prefix) {
+			continue
+		}
+		fn([]byte(key))
+	}
+	return nil
+}
+
+// prefixEnd returns the smallest key greater than all keys with the prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package hostenv
+
+import (
+	"bytes"
+	"errors"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+	"sort"
+)
+
+type StorageWrite struct {
+	Contract Bytes `json:"contract"`
+	Key      Bytes `json:"key"`
+	Value    Bytes `json:"value,omitempty"`
+	Removed  bool  `json:"removed,omitempty"`
+}
+
+type BalanceChange struct {
+	Address Bytes    `json:"address"`
+	Delta   *big.Int `json:"delta"`
+}
+
+type DeployedContract struct {
+	Contract Bytes `json:"contract"`
+	Code     Bytes `json:"code"`
+}
+
+// StateDiff is the write-set of an execution. Entries are sorted by contract, address and key.
+type StateDiff struct {
+	Storage  []StorageWrite     `json:"storage"`
+	Balances []BalanceChange    `json:"balances"`
+	Deployed []DeployedContract `json:"deployed"`
+	Burnt    *big.Int           `json:"burnt"`
+}
+
+func (d *StateDiff) IsEmpty() bool {
+	return len(d.Storage) == 0 && len(d.Balances) == 0 && len(d.Deployed) == 0 && d.Burnt.Sign() == 0
+}
+
+type storageWrite struct {
+	value   []byte
+	removed bool
+}
+
+type diff struct {
+	storage  map[lib.Address]map[string]storageWrite
+	balances map[lib.Address]*big.Int
+	deployed map[lib.Address][]byte
+	burnt    *big.Int
+}
+
+func newDiff() *diff {
+	return &diff{
+		storage:  map[lib.Address]map[string]storageWrite{},
+		balances: map[lib.Address]*big.Int{},
+		deployed: map[lib.Address][]byte{},
+		burnt:    big.NewInt(0),
+	}
+}
+
+func (d *diff) setStorage(contract lib.Address, key string, write storageWrite) {
+	writes, ok := d.storage[contract]
+	if !ok {
+		writes = map[string]storageWrite{}
+		d.storage[contract] = writes
+	}
+	writes[key] = write
+}
+
+func (d *diff) addBalance(addr lib.Address, delta *big.Int) {
+	balance, ok := d.balances[addr]
+	if !ok {
+		balance = big.NewInt(0)
+		d.balances[addr] = balance
+	}
+	balance.Add(balance, delta)
+}
+
+func (d *diff) merge(other *diff) {
+	for contract, writes := range other.storage {
+		for key, write := range writes {
+			d.setStorage(contract, key, write)
+		}
+	}
+	for addr, delta := range other.balances {
+		d.addBalance(addr, delta)
+	}
+	for contract, code := range other.deployed {
+		d.deployed[contract] = code
+	}
+	d.burnt.Add(d.burnt, other.burnt)
+}
+
+func (d *diff) copy() *diff {
+	res := newDiff()
+	res.merge(d)
+	return res
+}
+
+func (d *diff) stateDiff() *StateDiff {
+	res := &StateDiff{
+		Storage:  []StorageWrite{},
+		Balances: []BalanceChange{},
+		Deployed: []DeployedContract{},
+		Burnt:    new(big.Int).Set(d.burnt),
+	}
+	for contract, writes := range d.storage {
+		for key, write := range writes {
+			res.Storage = append(res.Storage, StorageWrite{
+				Contract: copyAddress(contract),
+				Key:      Bytes(key),
+				Value:    write.value,
+				Removed:  write.removed,
+			})
+		}
+	}
+	sort.Slice(res.Storage, func(i, j int) bool {
+		if c := bytes.Compare(res.Storage[i].Contract, res.Storage[j].Contract); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(res.Storage[i].Key, res.Storage[j].Key) < 0
+	})
+	for addr, delta := range d.balances {
+		if delta.Sign() == 0 {
+			continue
+		}
+		res.Balances = append(res.Balances, BalanceChange{Address: copyAddress(addr), Delta: new(big.Int).Set(delta)})
+	}
+	sort.Slice(res.Balances, func(i, j int) bool {
+		return bytes.Compare(res.Balances[i].Address, res.Balances[j].Address) < 0
+	})
+	for contract, code := range d.deployed {
+		res.Deployed = append(res.Deployed, DeployedContract{Contract: copyAddress(contract), Code: code})
+	}
+	sort.Slice(res.Deployed, func(i, j int) bool {
+		return bytes.Compare(res.Deployed[i].Contract, res.Deployed[j].Contract) < 0
+	})
+	return res
+}
+
+func copyAddress(addr lib.Address) Bytes {
+	return append(Bytes{}, addr[:]...)
+}
+
+// DiffHostEnv is a lib.HostEnv decorator collecting the write-set of an execution.
+// Changes made by a sub call are added to the write-set of its caller only when the sub env is committed.
+type DiffHostEnv struct {
+	lib.HostEnv
+	parent   *DiffHostEnv
+	contract lib.Address
+	diff     *diff
+	// parentDiff is the write-set of the caller when the sub env is created, Revert restores it
+	parentDiff *diff
+}
+
+func NewDiffHostEnv(env lib.HostEnv, contract lib.Address) *DiffHostEnv {
+	return &DiffHostEnv{
+		HostEnv:  env,
+		contract: contract,
+		diff:     newDiff(),
+	}
+}
+
+// StateDiff returns changes made by the env including committed sub calls.
+func (e *DiffHostEnv) StateDiff() *StateDiff {
+	return e.diff.stateDiff()
+}
+
+func (e *DiffHostEnv) SetStorage(meter *lib.GasMeter, key []byte, value []byte) {
+	e.HostEnv.SetStorage(meter, key, value)
+	e.diff.setStorage(e.contract, string(key), storageWrite{value: value})
+}
+
+func (e *DiffHostEnv) RemoveStorage(meter *lib.GasMeter, key []byte) {
+	e.HostEnv.RemoveStorage(meter, key)
+	e.diff.setStorage(e.contract, string(key), storageWrite{removed: true})
+}
+
+func (e *DiffHostEnv) SubBalance(meter *lib.GasMeter, amount *big.Int) error {
+	if err := e.HostEnv.SubBalance(meter, amount); err != nil {
+		return err
+	}
+	e.diff.addBalance(e.contract, new(big.Int).Neg(amount))
+	return nil
+}
+
+func (e *DiffHostEnv) AddBalance(meter *lib.GasMeter, address lib.Address, amount *big.Int) {
+	e.HostEnv.AddBalance(meter, address, amount)
+	e.diff.addBalance(address, amount)
+}
+
+func (e *DiffHostEnv) Burn(meter *lib.GasMeter, amount *big.Int) error {
+	if err := e.HostEnv.Burn(meter, amount); err != nil {
+		return err
+	}
+	e.diff.addBalance(e.contract, new(big.Int).Neg(amount))
+	e.diff.burnt.Add(e.diff.burnt, amount)
+	return nil
+}
+
+// transferer is implemented by envs moving coins outside of contract executions, like memory.Env.
+type transferer interface {
+	Transfer(from lib.Address, to lib.Address, amount *big.Int) error
+}
+
+// Transfer moves the amount with the wrapped env and adds it to the write-set,
+// so the amount paid to the called contract is reported together with the changes made by the execution.
+func (e *DiffHostEnv) Transfer(from lib.Address, to lib.Address, amount *big.Int) error {
+	env, ok := e.HostEnv.(transferer)
+	if !ok {
+		return errors.New("host env does not support transfers")
+	}
+	if err := env.Transfer(from, to, amount); err != nil {
+		return err
+	}
+	e.diff.addBalance(from, new(big.Int).Neg(amount))
+	e.diff.addBalance(to, amount)
+	return nil
+}
+
+func (e *DiffHostEnv) Deploy(code []byte) {
+	e.HostEnv.Deploy(code)
+	e.diff.deployed[e.contract] = code
+}
+
+func (e *DiffHostEnv) CreateSubEnv(contract lib.Address, method string, payAmount *big.Int, isDeploy bool) (lib.HostEnv, error) {
+	subEnv, err := e.HostEnv.CreateSubEnv(contract, method, payAmount, isDeploy)
+	if err != nil {
+		return nil, err
+	}
+	res := &DiffHostEnv{
+		HostEnv:    subEnv,
+		parent:     e,
+		contract:   contract,
+		diff:       newDiff(),
+		parentDiff: e.diff.copy(),
+	}
+	if payAmount != nil && payAmount.Sign() > 0 {
+		res.diff.addBalance(e.contract, new(big.Int).Neg(payAmount))
+		res.diff.addBalance(contract, payAmount)
+	}
+	return res, nil
+}
+
+// Revert discards changes of a failed sub env, the write-set of the caller is restored as it was
+// when the sub env was created, so changes merged by committed sub calls of the failed env are discarded too.
+func (e *DiffHostEnv) Revert() error {
+	if e.parent != nil {
+		e.parent.diff = e.parentDiff
+		e.parentDiff = e.parent.diff.copy()
+	}
+	e.diff = newDiff()
+	return revert(e.HostEnv)
+}
+
+// Commit merges changes of a sub env into the write-set of its caller, committing an env again is a no-op.
+func (e *DiffHostEnv) Commit() {
+	e.HostEnv.Commit()
+	if e.parent != nil {
+		e.parent.diff.merge(e.diff)
+		e.diff = newDiff()
+	}
+}
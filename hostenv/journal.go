@@ -0,0 +1,385 @@
+package hostenv
+
+import (
+	"errors"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+)
+
+const (
+	journalStorage byte = iota
+	journalBalance
+	journalCodeHash
+	journalCode
+	journalBurnt
+)
+
+// ErrInvalidSavepoint is returned by Revert for a savepoint taken before the last Commit or not taken yet.
+var ErrInvalidSavepoint = errors.New("invalid savepoint")
+
+type journalValue struct {
+	value   []byte
+	removed bool
+}
+
+type journalEntry struct {
+	key string
+	// prev is the overlay value before the change, nil if the key was not in the overlay
+	prev *journalValue
+	// event is set for an emitted event, reverting it drops the last event
+	event bool
+}
+
+// Journal is a write overlay of a memory.Store recording an undo log of every change.
+// A savepoint is a position in the log, Revert undoes changes made after it and Commit flushes the overlay to the store.
+type Journal struct {
+	store   memory.Store
+	overlay map[string]*journalValue
+	log     []journalEntry
+	events  []memory.Event
+	// base is the savepoint of the last commit, savepoints below it are invalid
+	base  int
+	burnt *big.Int
+}
+
+func NewJournal(store memory.Store) *Journal {
+	return &Journal{
+		store:   store,
+		overlay: map[string]*journalValue{},
+		burnt:   big.NewInt(0),
+	}
+}
+
+func journalKey(kind byte, id []byte, key []byte) string {
+	res := make([]byte, 0, 1+len(id)+len(key))
+	res = append(res, kind)
+	res = append(res, id...)
+	return string(append(res, key...))
+}
+
+func (j *Journal) get(key string) (*journalValue, bool) {
+	value, ok := j.overlay[key]
+	return value, ok
+}
+
+func (j *Journal) set(key string, value []byte) {
+	j.log = append(j.log, journalEntry{key: key, prev: j.overlay[key]})
+	j.overlay[key] = &journalValue{value: value, removed: value == nil}
+}
+
+func (j *Journal) Savepoint() int {
+	return j.base + len(j.log)
+}
+
+// Revert undoes changes and drops events recorded after the savepoint.
+func (j *Journal) Revert(savepoint int) error {
+	pos := savepoint - j.base
+	if pos < 0 || pos > len(j.log) {
+		return fmt.Errorf("%w: %v", ErrInvalidSavepoint, savepoint)
+	}
+	for i := len(j.log) - 1; i >= pos; i-- {
+		entry := j.log[i]
+		switch {
+		case entry.event:
+			j.events = j.events[:len(j.events)-1]
+		case entry.prev == nil:
+			delete(j.overlay, entry.key)
+		default:
+			j.overlay[entry.key] = entry.prev
+		}
+	}
+	j.log = j.log[:pos]
+	return nil
+}
+
+// Commit flushes the overlay to the store, committed changes cannot be reverted and earlier savepoints become invalid.
+func (j *Journal) Commit() {
+	for key, value := range j.overlay {
+		id := []byte(key[1:])
+		switch key[0] {
+		case journalStorage:
+			j.store.SetStorage(newAddress(id), id[len(lib.Address{}):], value.value)
+		case journalBalance:
+			j.store.SetBalance(newAddress(id), new(big.Int).SetBytes(value.value))
+		case journalCodeHash:
+			j.store.SetContractCodeHash(newAddress(id), value.value)
+		case journalCode:
+			j.store.SetCode(id, value.value)
+		case journalBurnt:
+			j.burnt.Add(j.burnt, new(big.Int).SetBytes(value.value))
+		}
+	}
+	j.overlay = map[string]*journalValue{}
+	// savepoints after the commit are above any savepoint taken before it
+	j.base += len(j.log) + 1
+	j.log = nil
+}
+
+// Events returns events emitted and not reverted.
+func (j *Journal) Events() []memory.Event {
+	return j.events
+}
+
+func (j *Journal) addEvent(event memory.Event) {
+	j.log = append(j.log, journalEntry{event: true})
+	j.events = append(j.events, event)
+}
+
+func (j *Journal) GetStorage(contract lib.Address, key []byte) []byte {
+	if value, ok := j.get(journalKey(journalStorage, contract[:], key)); ok {
+		return value.value
+	}
+	return j.store.GetStorage(contract, key)
+}
+
+func (j *Journal) SetStorage(contract lib.Address, key []byte, value []byte) {
+	j.set(journalKey(journalStorage, contract[:], key), value)
+}
+
+func (j *Journal) Balance(addr lib.Address) *big.Int {
+	if value, ok := j.get(journalKey(journalBalance, addr[:], nil)); ok {
+		return new(big.Int).SetBytes(value.value)
+	}
+	return j.store.Balance(addr)
+}
+
+func (j *Journal) SetBalance(addr lib.Address, balance *big.Int) {
+	j.set(journalKey(journalBalance, addr[:], nil), balance.Bytes())
+}
+
+func (j *Journal) ContractCodeHash(contract lib.Address) []byte {
+	if value, ok := j.get(journalKey(journalCodeHash, contract[:], nil)); ok {
+		return value.value
+	}
+	return j.store.ContractCodeHash(contract)
+}
+
+func (j *Journal) SetContractCodeHash(contract lib.Address, hash []byte) {
+	j.set(journalKey(journalCodeHash, contract[:], nil), hash)
+}
+
+func (j *Journal) Code(hash []byte) []byte {
+	if value, ok := j.get(journalKey(journalCode, hash, nil)); ok {
+		return value.value
+	}
+	return j.store.Code(hash)
+}
+
+func (j *Journal) SetCode(hash []byte, code []byte) {
+	j.set(journalKey(journalCode, hash, nil), code)
+}
+
+// Burnt returns the amount burnt by changes which are not reverted.
+func (j *Journal) Burnt() *big.Int {
+	res := new(big.Int).Set(j.burnt)
+	if value, ok := j.get(journalKey(journalBurnt, nil, nil)); ok {
+		res.Add(res, new(big.Int).SetBytes(value.value))
+	}
+	return res
+}
+
+func (j *Journal) addBurnt(amount *big.Int) {
+	key := journalKey(journalBurnt, nil, nil)
+	burnt := new(big.Int).Set(amount)
+	if value, ok := j.get(key); ok {
+		burnt.Add(burnt, new(big.Int).SetBytes(value.value))
+	}
+	j.set(key, burnt.Bytes())
+}
+
+func (j *Journal) Identity(addr lib.Address) []byte {
+	return j.store.Identity(addr)
+}
+
+func newAddress(data []byte) lib.Address {
+	res := lib.Address{}
+	copy(res[:], data)
+	return res
+}
+
+// revert reverts the env if it implements lib.RevertibleHostEnv, wrapping envs forward reverts of their sub envs with it.
+func revert(env lib.HostEnv) error {
+	if revertible, ok := env.(lib.RevertibleHostEnv); ok {
+		return revertible.Revert()
+	}
+	return nil
+}
+
+// JournalHostEnv is a lib.HostEnv keeping contract state in a Journal and answering chain queries
+// (block data, identities, hashing) with the base env. Every sub env starts at a savepoint
+// and the binding reverts it as soon as its execution fails,
+// so any host gets atomic nested calls by providing chain queries and a memory.Store.
+type JournalHostEnv struct {
+	lib.HostEnv
+	journal   *Journal
+	parent    *JournalHostEnv
+	ctx       memory.Context
+	depth     int
+	savepoint int
+}
+
+func NewJournalHostEnv(base lib.HostEnv, store memory.Store, ctx memory.Context) *JournalHostEnv {
+	if ctx.PayAmount == nil {
+		ctx.PayAmount = big.NewInt(0)
+	}
+	return &JournalHostEnv{
+		HostEnv: base,
+		journal: NewJournal(store),
+		ctx:     ctx,
+	}
+}
+
+func (e *JournalHostEnv) Journal() *Journal {
+	return e.journal
+}
+
+func (e *JournalHostEnv) Context() memory.Context {
+	return e.ctx
+}
+
+// Revert discards changes made by the env and its sub envs, the root env discards changes since its last commit.
+func (e *JournalHostEnv) Revert() error {
+	return e.journal.Revert(e.savepoint)
+}
+
+func (e *JournalHostEnv) SetStorage(meter *lib.GasMeter, key []byte, value []byte) {
+	e.journal.SetStorage(e.ctx.Contract, key, value)
+}
+
+func (e *JournalHostEnv) GetStorage(meter *lib.GasMeter, key []byte) []byte {
+	return e.journal.GetStorage(e.ctx.Contract, key)
+}
+
+func (e *JournalHostEnv) RemoveStorage(meter *lib.GasMeter, key []byte) {
+	e.journal.SetStorage(e.ctx.Contract, key, nil)
+}
+
+func (e *JournalHostEnv) Balance(meter *lib.GasMeter) *big.Int {
+	return e.journal.Balance(e.ctx.Contract)
+}
+
+func (e *JournalHostEnv) subBalance(addr lib.Address, amount *big.Int) error {
+	balance := e.journal.Balance(addr)
+	if balance.Cmp(amount) < 0 {
+		return memory.ErrInsufficientBalance
+	}
+	e.journal.SetBalance(addr, balance.Sub(balance, amount))
+	return nil
+}
+
+func (e *JournalHostEnv) addBalance(addr lib.Address, amount *big.Int) {
+	e.journal.SetBalance(addr, new(big.Int).Add(e.journal.Balance(addr), amount))
+}
+
+func (e *JournalHostEnv) CreateSubEnv(contract lib.Address, method string, payAmount *big.Int, isDeploy bool) (lib.HostEnv, error) {
+	if e.depth >= memory.MaxCallDepth {
+		return nil, memory.ErrMaxCallDepth
+	}
+	sub := &JournalHostEnv{
+		HostEnv: e.HostEnv,
+		journal: e.journal,
+		parent:  e,
+		ctx: memory.Context{
+			Caller:         e.ctx.Contract,
+			OriginalCaller: e.ctx.OriginalCaller,
+			Contract:       contract,
+			Method:         method,
+			PayAmount:      payAmount,
+			IsDeploy:       isDeploy,
+		},
+		depth:     e.depth + 1,
+		savepoint: e.journal.Savepoint(),
+	}
+	if payAmount.Sign() > 0 {
+		if err := e.subBalance(e.ctx.Contract, payAmount); err != nil {
+			return nil, err
+		}
+		e.addBalance(contract, payAmount)
+	}
+	return sub, nil
+}
+
+func (e *JournalHostEnv) GetCode(addr lib.Address) []byte {
+	hash := e.journal.ContractCodeHash(addr)
+	if hash == nil {
+		return nil
+	}
+	return e.journal.Code(hash)
+}
+
+// Commit of a sub env is a no-op, its changes stay in the journal shared with its caller.
+// Commit of the root env flushes the journal to the store and takes a new savepoint for Revert.
+func (e *JournalHostEnv) Commit() {
+	if e.parent != nil {
+		return
+	}
+	e.journal.Commit()
+	e.savepoint = e.journal.Savepoint()
+}
+
+func (e *JournalHostEnv) Caller(meter *lib.GasMeter) lib.Address {
+	return e.ctx.Caller
+}
+
+func (e *JournalHostEnv) OriginalCaller(meter *lib.GasMeter) lib.Address {
+	return e.ctx.OriginalCaller
+}
+
+func (e *JournalHostEnv) SubBalance(meter *lib.GasMeter, amount *big.Int) error {
+	return e.subBalance(e.ctx.Contract, amount)
+}
+
+func (e *JournalHostEnv) AddBalance(meter *lib.GasMeter, address lib.Address, amount *big.Int) {
+	e.addBalance(address, amount)
+}
+
+func (e *JournalHostEnv) ContractAddress(meter *lib.GasMeter) lib.Address {
+	return e.ctx.Contract
+}
+
+func (e *JournalHostEnv) Deploy(code []byte) {
+	hash := memory.CodeHash(code)
+	e.journal.SetCode(hash, code)
+	e.journal.SetContractCodeHash(e.ctx.Contract, hash)
+}
+
+func (e *JournalHostEnv) OwnCode(meter *lib.GasMeter) []byte {
+	return e.GetCode(e.ctx.Contract)
+}
+
+func (e *JournalHostEnv) CodeHash(meter *lib.GasMeter) []byte {
+	return e.journal.ContractCodeHash(e.ctx.Contract)
+}
+
+func (e *JournalHostEnv) Event(meter *lib.GasMeter, name string, args ...[]byte) {
+	e.journal.addEvent(memory.Event{
+		Contract: e.ctx.Contract,
+		Name:     name,
+		Args:     args,
+	})
+}
+
+func (e *JournalHostEnv) ReadContractData(meter *lib.GasMeter, address lib.Address, key []byte) []byte {
+	return e.journal.GetStorage(address, key)
+}
+
+func (e *JournalHostEnv) ContractCodeHash(addr lib.Address) *[]byte {
+	if hash := e.journal.ContractCodeHash(addr); hash != nil {
+		return &hash
+	}
+	return nil
+}
+
+func (e *JournalHostEnv) PayAmount(meter *lib.GasMeter) *big.Int {
+	return new(big.Int).Set(e.ctx.PayAmount)
+}
+
+func (e *JournalHostEnv) Burn(meter *lib.GasMeter, amount *big.Int) error {
+	if err := e.subBalance(e.ctx.Contract, amount); err != nil {
+		return err
+	}
+	e.journal.addBurnt(amount)
+	return nil
+}
@@ -0,0 +1,519 @@
+package memory
+
+import (
+	"errors"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"golang.org/x/crypto/sha3"
+	"math/big"
+)
+
+const MaxStorageKeyLength = 32
+const MaxCallDepth = 16
+
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrMaxCallDepth        = errors.New("max call depth reached")
+)
+
+type Block struct {
+	Number       uint64
+	Timestamp    int64
+	Seed         []byte
+	MinFeePerGas *big.Int
+	NetworkSize  uint64
+	Epoch        uint16
+	GlobalState  []byte
+	// Headers holds marshaled block headers by height
+	Headers map[uint64][]byte
+}
+
+type Context struct {
+	Caller         lib.Address
+	OriginalCaller lib.Address
+	Contract       lib.Address
+	Method         string
+	PayAmount      *big.Int
+	IsDeploy       bool
+}
+
+type Event struct {
+	Contract lib.Address
+	Name     string
+	Args     [][]byte
+}
+
+type storageValue struct {
+	value   []byte
+	removed bool
+}
+
+// Env is a lib.HostEnv keeping all changes in caches until they are committed.
+// Commit of a sub env merges its changes into the parent env and Commit of the root env flushes them to the Store,
+// committed changes are cleared, so repeated commits are no-ops. Revert of a sub env discards its changes
+// and restores the parent env as it was when the sub env was created.
+type Env struct {
+	parent *Env
+	store  Store
+	block  *Block
+	ctx    Context
+	depth  int
+	debug  bool
+
+	ecrecover func(data []byte, signature []byte) []byte
+
+	storageCache  map[lib.Address]map[string]*storageValue
+	balancesCache map[lib.Address]*big.Int
+	deployedCache map[lib.Address][]byte
+	events        []Event
+	burnt         *big.Int
+
+	// parentCaches are caches of the parent env when the sub env is created, Revert restores them
+	parentCaches *caches
+
+	committedEvents []Event
+	committedBurnt  *big.Int
+}
+
+func NewEnv(store Store, block *Block, ctx Context) *Env {
+	if ctx.PayAmount == nil {
+		ctx.PayAmount = big.NewInt(0)
+	}
+	env := newEnv(nil, store, block, ctx)
+	env.committedBurnt = big.NewInt(0)
+	return env
+}
+
+func newEnv(parent *Env, store Store, block *Block, ctx Context) *Env {
+	return &Env{
+		parent:        parent,
+		store:         store,
+		block:         block,
+		ctx:           ctx,
+		storageCache:  map[lib.Address]map[string]*storageValue{},
+		balancesCache: map[lib.Address]*big.Int{},
+		deployedCache: map[lib.Address][]byte{},
+		burnt:         big.NewInt(0),
+	}
+}
+
+type caches struct {
+	storage  map[lib.Address]map[string]*storageValue
+	balances map[lib.Address]*big.Int
+	deployed map[lib.Address][]byte
+	events   []Event
+	burnt    *big.Int
+}
+
+func (e *Env) copyCaches() *caches {
+	res := &caches{
+		storage:  make(map[lib.Address]map[string]*storageValue, len(e.storageCache)),
+		balances: make(map[lib.Address]*big.Int, len(e.balancesCache)),
+		deployed: make(map[lib.Address][]byte, len(e.deployedCache)),
+		events:   append([]Event{}, e.events...),
+		burnt:    new(big.Int).Set(e.burnt),
+	}
+	for contract, cache := range e.storageCache {
+		values := make(map[string]*storageValue, len(cache))
+		for key, value := range cache {
+			values[key] = value
+		}
+		res.storage[contract] = values
+	}
+	for addr, balance := range e.balancesCache {
+		res.balances[addr] = balance
+	}
+	for addr, code := range e.deployedCache {
+		res.deployed[addr] = code
+	}
+	return res
+}
+
+func (e *Env) restoreCaches(c *caches) {
+	e.storageCache = c.storage
+	e.balancesCache = c.balances
+	e.deployedCache = c.deployed
+	e.events = c.events
+	e.burnt = c.burnt
+}
+
+func (e *Env) SetDebug(debug bool) {
+	e.debug = debug
+}
+
+// SetEcrecover sets the function used to recover public keys, Ecrecover returns nil if it is not set.
+func (e *Env) SetEcrecover(fn func(data []byte, signature []byte) []byte) {
+	e.ecrecover = fn
+}
+
+func (e *Env) Context() Context {
+	return e.ctx
+}
+
+func (e *Env) Depth() int {
+	return e.depth
+}
+
+// Events returns events committed to the root env.
+func (e *Env) Events() []Event {
+	return e.root().committedEvents
+}
+
+// Burnt returns the amount burnt by committed actions.
+func (e *Env) Burnt() *big.Int {
+	return new(big.Int).Set(e.root().committedBurnt)
+}
+
+func (e *Env) root() *Env {
+	env := e
+	for env.parent != nil {
+		env = env.parent
+	}
+	return env
+}
+
+func (e *Env) SetStorage(meter *lib.GasMeter, key []byte, value []byte) {
+	if len(key) > MaxStorageKeyLength {
+		panic("key is too big")
+	}
+	e.setStorage(e.ctx.Contract, key, &storageValue{value: value})
+	meter.ConsumeGas(uint64(10 * (len(key) + len(value))))
+}
+
+func (e *Env) GetStorage(meter *lib.GasMeter, key []byte) []byte {
+	value := e.readStorage(e.ctx.Contract, key)
+	meter.ConsumeGas(uint64(10 * len(value)))
+	return value
+}
+
+func (e *Env) RemoveStorage(meter *lib.GasMeter, key []byte) {
+	e.setStorage(e.ctx.Contract, key, &storageValue{removed: true})
+	meter.ConsumeGas(10)
+}
+
+func (e *Env) setStorage(contract lib.Address, key []byte, value *storageValue) {
+	cache, ok := e.storageCache[contract]
+	if !ok {
+		cache = map[string]*storageValue{}
+		e.storageCache[contract] = cache
+	}
+	cache[string(key)] = value
+}
+
+func (e *Env) readStorage(contract lib.Address, key []byte) []byte {
+	if cache, ok := e.storageCache[contract]; ok {
+		if value, ok := cache[string(key)]; ok {
+			if value.removed {
+				return nil
+			}
+			return value.value
+		}
+	}
+	if e.parent != nil {
+		return e.parent.readStorage(contract, key)
+	}
+	return e.store.GetStorage(contract, key)
+}
+
+func (e *Env) BlockNumber(meter *lib.GasMeter) uint64 {
+	return e.block.Number
+}
+
+func (e *Env) BlockTimestamp(meter *lib.GasMeter) int64 {
+	return e.block.Timestamp
+}
+
+func (e *Env) MinFeePerGas(meter *lib.GasMeter) *big.Int {
+	if e.block.MinFeePerGas == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(e.block.MinFeePerGas)
+}
+
+func (e *Env) Balance(meter *lib.GasMeter) *big.Int {
+	return e.balance(e.ctx.Contract)
+}
+
+// BalanceOf returns the balance of the address including uncommitted changes.
+func (e *Env) BalanceOf(addr lib.Address) *big.Int {
+	return e.balance(addr)
+}
+
+// Transfer moves the amount between addresses, it is applied to the env caches like any other change.
+func (e *Env) Transfer(from lib.Address, to lib.Address, amount *big.Int) error {
+	if err := e.subBalance(from, amount); err != nil {
+		return err
+	}
+	e.addBalance(to, amount)
+	return nil
+}
+
+func (e *Env) balance(addr lib.Address) *big.Int {
+	if balance, ok := e.balancesCache[addr]; ok {
+		return new(big.Int).Set(balance)
+	}
+	if e.parent != nil {
+		return e.parent.balance(addr)
+	}
+	return e.store.Balance(addr)
+}
+
+func (e *Env) setBalance(addr lib.Address, balance *big.Int) {
+	e.balancesCache[addr] = balance
+}
+
+func (e *Env) addBalance(addr lib.Address, amount *big.Int) {
+	e.setBalance(addr, new(big.Int).Add(e.balance(addr), amount))
+}
+
+func (e *Env) subBalance(addr lib.Address, amount *big.Int) error {
+	balance := e.balance(addr)
+	if balance.Cmp(amount) < 0 {
+		return ErrInsufficientBalance
+	}
+	e.setBalance(addr, balance.Sub(balance, amount))
+	return nil
+}
+
+func (e *Env) BlockSeed(meter *lib.GasMeter) []byte {
+	return e.block.Seed
+}
+
+func (e *Env) NetworkSize(meter *lib.GasMeter) uint64 {
+	return e.block.NetworkSize
+}
+
+func (e *Env) Identity(meter *lib.GasMeter, address lib.Address) []byte {
+	return e.store.Identity(address)
+}
+
+func (e *Env) CreateSubEnv(contract lib.Address, method string, payAmount *big.Int, isDeploy bool) (lib.HostEnv, error) {
+	if e.depth >= MaxCallDepth {
+		return nil, ErrMaxCallDepth
+	}
+	subEnv := newEnv(e, e.store, e.block, Context{
+		Caller:         e.ctx.Contract,
+		OriginalCaller: e.ctx.OriginalCaller,
+		Contract:       contract,
+		Method:         method,
+		PayAmount:      payAmount,
+		IsDeploy:       isDeploy,
+	})
+	subEnv.depth = e.depth + 1
+	subEnv.debug = e.debug
+	subEnv.ecrecover = e.ecrecover
+	subEnv.parentCaches = e.copyCaches()
+	if payAmount.Sign() > 0 {
+		if err := subEnv.subBalance(e.ctx.Contract, payAmount); err != nil {
+			return nil, err
+		}
+		subEnv.addBalance(contract, payAmount)
+	}
+	return subEnv, nil
+}
+
+func (e *Env) GetCode(addr lib.Address) []byte {
+	if code, ok := e.deployedCache[addr]; ok {
+		return code
+	}
+	if e.parent != nil {
+		return e.parent.GetCode(addr)
+	}
+	hash := e.store.ContractCodeHash(addr)
+	if hash == nil {
+		return nil
+	}
+	return e.store.Code(hash)
+}
+
+// Commit merges changes of a sub env into its parent or flushes changes of the root env to the Store.
+// The binding commits the calling env after every successful sub call as well, a calling env which fails later
+// is reverted, which discards the changes merged into its own parent.
+func (e *Env) Commit() {
+	if e.parent != nil {
+		e.commitToParent()
+	} else {
+		e.commitToStore()
+	}
+	e.clear()
+}
+
+func (e *Env) commitToParent() {
+	p := e.parent
+	for contract, cache := range e.storageCache {
+		for key, value := range cache {
+			p.setStorage(contract, []byte(key), value)
+		}
+	}
+	for addr, balance := range e.balancesCache {
+		p.setBalance(addr, balance)
+	}
+	for addr, code := range e.deployedCache {
+		p.deployedCache[addr] = code
+	}
+	p.events = append(p.events, e.events...)
+	p.burnt.Add(p.burnt, e.burnt)
+}
+
+// Revert discards changes of the env, a sub env also restores its parent as it was when the sub env was created.
+func (e *Env) Revert() error {
+	if e.parent != nil {
+		e.parent.restoreCaches(e.parentCaches)
+		e.parentCaches = e.parent.copyCaches()
+	}
+	e.clear()
+	return nil
+}
+
+func (e *Env) commitToStore() {
+	for contract, cache := range e.storageCache {
+		for key, value := range cache {
+			if value.removed {
+				e.store.SetStorage(contract, []byte(key), nil)
+			} else {
+				e.store.SetStorage(contract, []byte(key), value.value)
+			}
+		}
+	}
+	for addr, balance := range e.balancesCache {
+		e.store.SetBalance(addr, balance)
+	}
+	for addr, code := range e.deployedCache {
+		hash := CodeHash(code)
+		e.store.SetCode(hash, code)
+		e.store.SetContractCodeHash(addr, hash)
+	}
+	e.committedEvents = append(e.committedEvents, e.events...)
+	e.committedBurnt.Add(e.committedBurnt, e.burnt)
+}
+
+func (e *Env) clear() {
+	e.storageCache = map[lib.Address]map[string]*storageValue{}
+	e.balancesCache = map[lib.Address]*big.Int{}
+	e.deployedCache = map[lib.Address][]byte{}
+	e.events = nil
+	e.burnt = big.NewInt(0)
+}
+
+func (e *Env) Caller(meter *lib.GasMeter) lib.Address {
+	return e.ctx.Caller
+}
+
+func (e *Env) OriginalCaller(meter *lib.GasMeter) lib.Address {
+	return e.ctx.OriginalCaller
+}
+
+func (e *Env) SubBalance(meter *lib.GasMeter, amount *big.Int) error {
+	return e.subBalance(e.ctx.Contract, amount)
+}
+
+func (e *Env) AddBalance(meter *lib.GasMeter, address lib.Address, amount *big.Int) {
+	e.addBalance(address, amount)
+}
+
+func (e *Env) ContractAddress(meter *lib.GasMeter) lib.Address {
+	return e.ctx.Contract
+}
+
+func (e *Env) ContractAddr(meter *lib.GasMeter, code []byte, args []byte, nonce []byte) lib.Address {
+	return ContractAddress(CodeHash(code), args, nonce)
+}
+
+func (e *Env) Deploy(code []byte) {
+	e.deployedCache[e.ctx.Contract] = code
+}
+
+func (e *Env) ContractAddrByHash(meter *lib.GasMeter, hash []byte, args []byte, nonce []byte) lib.Address {
+	return ContractAddress(hash, args, nonce)
+}
+
+func (e *Env) OwnCode(meter *lib.GasMeter) []byte {
+	return e.GetCode(e.ctx.Contract)
+}
+
+func (e *Env) CodeHash(meter *lib.GasMeter) []byte {
+	if hash := e.ContractCodeHash(e.ctx.Contract); hash != nil {
+		return *hash
+	}
+	return nil
+}
+
+func (e *Env) Event(meter *lib.GasMeter, name string, args ...[]byte) {
+	e.events = append(e.events, Event{
+		Contract: e.ctx.Contract,
+		Name:     name,
+		Args:     args,
+	})
+}
+
+func (e *Env) ReadContractData(meter *lib.GasMeter, address lib.Address, key []byte) []byte {
+	value := e.readStorage(address, key)
+	meter.ConsumeGas(uint64(10 * len(value)))
+	return value
+}
+
+func (e *Env) Epoch(meter *lib.GasMeter) uint16 {
+	return e.block.Epoch
+}
+
+func (e *Env) ContractCodeHash(addr lib.Address) *[]byte {
+	if code := e.GetCode(addr); code != nil {
+		hash := CodeHash(code)
+		return &hash
+	}
+	return nil
+}
+
+func (e *Env) PayAmount(meter *lib.GasMeter) *big.Int {
+	return new(big.Int).Set(e.ctx.PayAmount)
+}
+
+func (e *Env) IsDebug() bool {
+	return e.debug
+}
+
+func (e *Env) BlockHeader(meter *lib.GasMeter, height uint64) []byte {
+	return e.block.Headers[height]
+}
+
+func (e *Env) Keccak256(meter *lib.GasMeter, data []byte) []byte {
+	return keccak256(data)
+}
+
+func (e *Env) GlobalState(meter *lib.GasMeter) []byte {
+	return e.block.GlobalState
+}
+
+func (e *Env) Burn(meter *lib.GasMeter, amount *big.Int) error {
+	if err := e.subBalance(e.ctx.Contract, amount); err != nil {
+		return err
+	}
+	e.burnt.Add(e.burnt, amount)
+	return nil
+}
+
+func (e *Env) Ecrecover(meter *lib.GasMeter, data []byte, signature []byte) []byte {
+	if e.ecrecover == nil {
+		return nil
+	}
+	return e.ecrecover(data, signature)
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func CodeHash(code []byte) []byte {
+	return keccak256(code)
+}
+
+// ContractAddress computes the address of a contract deployed with the code hash, packed arguments and nonce.
+func ContractAddress(codeHash []byte, args []byte, nonce []byte) lib.Address {
+	hash := keccak256(codeHash, args, nonce)
+	res := lib.Address{}
+	copy(res[:], hash[len(hash)-len(res):])
+	return res
+}
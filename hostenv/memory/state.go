@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+)
+
+// Store is the committed state an Env reads from and flushes its changes to.
+type Store interface {
+	GetStorage(contract lib.Address, key []byte) []byte
+	// SetStorage removes the key if value is nil
+	SetStorage(contract lib.Address, key []byte, value []byte)
+	Balance(addr lib.Address) *big.Int
+	SetBalance(addr lib.Address, balance *big.Int)
+	ContractCodeHash(contract lib.Address) []byte
+	SetContractCodeHash(contract lib.Address, hash []byte)
+	Code(hash []byte) []byte
+	SetCode(hash []byte, code []byte)
+	Identity(addr lib.Address) []byte
+}
+
+// State is an in-memory Store.
+type State struct {
+	storage    map[lib.Address]map[string][]byte
+	balances   map[lib.Address]*big.Int
+	codeHashes map[lib.Address][]byte
+	codes      map[string][]byte
+	identities map[lib.Address][]byte
+}
+
+func NewState() *State {
+	return &State{
+		storage:    map[lib.Address]map[string][]byte{},
+		balances:   map[lib.Address]*big.Int{},
+		codeHashes: map[lib.Address][]byte{},
+		codes:      map[string][]byte{},
+		identities: map[lib.Address][]byte{},
+	}
+}
+
+func (s *State) GetStorage(contract lib.Address, key []byte) []byte {
+	if values, ok := s.storage[contract]; ok {
+		return values[string(key)]
+	}
+	return nil
+}
+
+func (s *State) SetStorage(contract lib.Address, key []byte, value []byte) {
+	values, ok := s.storage[contract]
+	if !ok {
+		if value == nil {
+			return
+		}
+		values = map[string][]byte{}
+		s.storage[contract] = values
+	}
+	if value == nil {
+		delete(values, string(key))
+		return
+	}
+	values[string(key)] = value
+}
+
+// StorageKeys returns all keys stored by the contract.
+func (s *State) StorageKeys(contract lib.Address) [][]byte {
+	var keys [][]byte
+	for key := range s.storage[contract] {
+		keys = append(keys, []byte(key))
+	}
+	return keys
+}
+
+func (s *State) Balance(addr lib.Address) *big.Int {
+	if balance, ok := s.balances[addr]; ok {
+		return new(big.Int).Set(balance)
+	}
+	return big.NewInt(0)
+}
+
+func (s *State) SetBalance(addr lib.Address, balance *big.Int) {
+	s.balances[addr] = new(big.Int).Set(balance)
+}
+
+func (s *State) ContractCodeHash(contract lib.Address) []byte {
+	return s.codeHashes[contract]
+}
+
+func (s *State) SetContractCodeHash(contract lib.Address, hash []byte) {
+	s.codeHashes[contract] = hash
+}
+
+// Contracts returns addresses of all deployed contracts.
+func (s *State) Contracts() []lib.Address {
+	var contracts []lib.Address
+	for contract := range s.codeHashes {
+		contracts = append(contracts, contract)
+	}
+	return contracts
+}
+
+func (s *State) Code(hash []byte) []byte {
+	return s.codes[string(hash)]
+}
+
+func (s *State) SetCode(hash []byte, code []byte) {
+	s.codes[string(hash)] = code
+}
+
+func (s *State) Identity(addr lib.Address) []byte {
+	return s.identities[addr]
+}
+
+func (s *State) SetIdentity(addr lib.Address, identity []byte) {
+	s.identities[addr] = identity
+}
+
+// Copy returns a deep copy of the state which can be used as a disposable snapshot.
+func (s *State) Copy() *State {
+	c := NewState()
+	for contract, values := range s.storage {
+		copied := make(map[string][]byte, len(values))
+		for key, value := range values {
+			copied[key] = value
+		}
+		c.storage[contract] = copied
+	}
+	for addr, balance := range s.balances {
+		c.balances[addr] = new(big.Int).Set(balance)
+	}
+	for contract, hash := range s.codeHashes {
+		c.codeHashes[contract] = hash
+	}
+	for hash, code := range s.codes {
+		c.codes[hash] = code
+	}
+	for addr, identity := range s.identities {
+		c.identities[addr] = identity
+	}
+	return c
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+)
+
+// stateJson is the JSON form of State, addresses, keys, hashes and values are hex encoded.
+type stateJson struct {
+	Storage    map[string]map[string]string `json:"storage"`
+	Balances   map[string]string            `json:"balances"`
+	CodeHashes map[string]string            `json:"codeHashes"`
+	Codes      map[string]string            `json:"codes"`
+	Identities map[string]string            `json:"identities"`
+}
+
+func (s *State) MarshalJSON() ([]byte, error) {
+	res := stateJson{
+		Storage:    map[string]map[string]string{},
+		Balances:   map[string]string{},
+		CodeHashes: map[string]string{},
+		Codes:      map[string]string{},
+		Identities: map[string]string{},
+	}
+	for contract, values := range s.storage {
+		encoded := make(map[string]string, len(values))
+		for key, value := range values {
+			encoded[hex.EncodeToString([]byte(key))] = hex.EncodeToString(value)
+		}
+		res.Storage[hex.EncodeToString(contract[:])] = encoded
+	}
+	for addr, balance := range s.balances {
+		res.Balances[hex.EncodeToString(addr[:])] = balance.String()
+	}
+	for contract, hash := range s.codeHashes {
+		res.CodeHashes[hex.EncodeToString(contract[:])] = hex.EncodeToString(hash)
+	}
+	for hash, code := range s.codes {
+		res.Codes[hex.EncodeToString([]byte(hash))] = hex.EncodeToString(code)
+	}
+	for addr, identity := range s.identities {
+		res.Identities[hex.EncodeToString(addr[:])] = hex.EncodeToString(identity)
+	}
+	return json.Marshal(res)
+}
+
+func (s *State) UnmarshalJSON(data []byte) error {
+	var res stateJson
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	*s = *NewState()
+	for contract, values := range res.Storage {
+		addr, err := decodeAddress(contract)
+		if err != nil {
+			return err
+		}
+		decoded := make(map[string][]byte, len(values))
+		for key, value := range values {
+			k, err := hex.DecodeString(key)
+			if err != nil {
+				return fmt.Errorf("invalid storage key %q: %w", key, err)
+			}
+			if decoded[string(k)], err = hex.DecodeString(value); err != nil {
+				return fmt.Errorf("invalid storage value %q: %w", value, err)
+			}
+		}
+		s.storage[addr] = decoded
+	}
+	for encodedAddr, value := range res.Balances {
+		addr, err := decodeAddress(encodedAddr)
+		if err != nil {
+			return err
+		}
+		balance, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return fmt.Errorf("invalid balance %q", value)
+		}
+		s.balances[addr] = balance
+	}
+	for contract, value := range res.CodeHashes {
+		addr, err := decodeAddress(contract)
+		if err != nil {
+			return err
+		}
+		if s.codeHashes[addr], err = hex.DecodeString(value); err != nil {
+			return fmt.Errorf("invalid code hash %q: %w", value, err)
+		}
+	}
+	for hash, value := range res.Codes {
+		h, err := hex.DecodeString(hash)
+		if err != nil {
+			return fmt.Errorf("invalid code hash %q: %w", hash, err)
+		}
+		if s.codes[string(h)], err = hex.DecodeString(value); err != nil {
+			return fmt.Errorf("invalid code of %q: %w", hash, err)
+		}
+	}
+	for encodedAddr, value := range res.Identities {
+		addr, err := decodeAddress(encodedAddr)
+		if err != nil {
+			return err
+		}
+		if s.identities[addr], err = hex.DecodeString(value); err != nil {
+			return fmt.Errorf("invalid identity %q: %w", value, err)
+		}
+	}
+	return nil
+}
+
+func decodeAddress(s string) (lib.Address, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != len(lib.Address{}) {
+		return lib.Address{}, fmt.Errorf("invalid address %q", s)
+	}
+	var res lib.Address
+	copy(res[:], data)
+	return res, nil
+}
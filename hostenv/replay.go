@@ -0,0 +1,480 @@
+package hostenv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+)
+
+// RecordedCall is a HostEnv call with its inputs and response, values are kept in their binary form.
+// GasUsed is the gas the call consumed on the meter, OutOfGas is set if the call ran out of gas.
+type RecordedCall struct {
+	Depth    int     `json:"depth"`
+	Method   string  `json:"method"`
+	Args     []Bytes `json:"args,omitempty"`
+	Result   Bytes   `json:"result"`
+	Error    string  `json:"error,omitempty"`
+	Panic    string  `json:"panic,omitempty"`
+	OutOfGas bool    `json:"outOfGas,omitempty"`
+	GasUsed  uint64  `json:"gasUsed,omitempty"`
+}
+
+func (c *RecordedCall) String() string {
+	return fmt.Sprintf("%v at depth %v", c.Method, c.Depth)
+}
+
+// RecordedExecution holds the inputs of the recorded execute or deploy.
+type RecordedExecution struct {
+	Code     Bytes   `json:"code"`
+	Method   string  `json:"method,omitempty"`
+	Args     []Bytes `json:"args,omitempty"`
+	Contract Bytes   `json:"contract"`
+	GasLimit uint64  `json:"gasLimit"`
+	IsDeploy bool    `json:"isDeploy,omitempty"`
+}
+
+// Recording is a serializable log of HostEnv calls made during an execution, so the execution can be
+// reproduced without the host state it ran against. GasSchedule and Forks are the rules the execution
+// is charged with, see NewGoAPI.
+type Recording struct {
+	Debug       bool               `json:"debug,omitempty"`
+	GasSchedule *lib.GasSchedule   `json:"gasSchedule,omitempty"`
+	Forks       *lib.ForkConfig    `json:"forks,omitempty"`
+	Execution   *RecordedExecution `json:"execution,omitempty"`
+	Calls       []RecordedCall     `json:"calls"`
+}
+
+func ParseRecording(data []byte) (*Recording, error) {
+	res := &Recording{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *Recording) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// NewGoAPI returns an api charging the execution with the gas schedule and the fork config of the recording,
+// build the api with it both to record the execution and to replay it, so the replay charges the same gas.
+func (r *Recording) NewGoAPI(env lib.HostEnv, gasMeter *lib.GasMeter) *lib.GoAPI {
+	api := lib.NewGoAPIWithGasSchedule(env, gasMeter, r.GasSchedule)
+	api.SetForkConfig(r.Forks)
+	return api
+}
+
+// Run executes the recorded execution with the api, which is built with NewGoAPI over a RecordingHostEnv
+// to record it or over a ReplayHostEnv to replay it.
+func (r *Recording) Run(api *lib.GoAPI) (*lib.ExecutionResult, error) {
+	e := r.Execution
+	if e == nil {
+		return nil, errors.New("recording has no execution")
+	}
+	args := make([][]byte, 0, len(e.Args))
+	for _, arg := range e.Args {
+		args = append(args, arg)
+	}
+	var contract lib.Address
+	copy(contract[:], e.Contract)
+	if e.IsDeploy {
+		return lib.DeployResult(api, e.Code, args, contract, e.GasLimit, r.Debug)
+	}
+	return lib.ExecuteResult(api, e.Code, e.Method, args, contract, e.GasLimit, r.Debug)
+}
+
+func u64Bytes(v uint64) []byte {
+	res := make([]byte, 8)
+	binary.BigEndian.PutUint64(res, v)
+	return res
+}
+
+func bytesU64(data []byte) uint64 {
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// bigBytes encodes a non-negative amount, nil stays nil.
+func bigBytes(v *big.Int) []byte {
+	if v == nil {
+		return nil
+	}
+	return append([]byte{}, v.Bytes()...)
+}
+
+func bytesBig(data []byte) *big.Int {
+	if data == nil {
+		return nil
+	}
+	return new(big.Int).SetBytes(data)
+}
+
+func boolBytes(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// RecordingHostEnv is a TracingHostEnv recording responses of the wrapped env to a Recording for a later replay.
+type RecordingHostEnv struct {
+	*TracingHostEnv
+	recording *Recording
+}
+
+func NewRecordingHostEnv(env lib.HostEnv) *RecordingHostEnv {
+	recording := &Recording{Debug: env.IsDebug()}
+	return &RecordingHostEnv{
+		TracingHostEnv: &TracingHostEnv{env: env, log: recording},
+		recording:      recording,
+	}
+}
+
+func (e *RecordingHostEnv) Recording() *Recording {
+	return e.recording
+}
+
+func (r *Recording) addCall(call *hostCall) {
+	recorded := RecordedCall{
+		Depth:   call.depth,
+		Method:  call.method,
+		Result:  recordedValue(call.result),
+		Error:   errorMessage(call.err),
+		GasUsed: call.gasUsed,
+	}
+	for _, a := range call.args {
+		if values, ok := a.Value.([][]byte); ok {
+			for _, value := range values {
+				recorded.Args = append(recorded.Args, value)
+			}
+			continue
+		}
+		recorded.Args = append(recorded.Args, recordedValue(a.Value))
+	}
+	if call.panic != nil {
+		if _, ok := call.panic.(lib.OutOfGas); ok {
+			recorded.OutOfGas = true
+		} else {
+			recorded.Panic = fmt.Sprint(call.panic)
+		}
+	}
+	r.Calls = append(r.Calls, recorded)
+}
+
+// recordedValue returns the binary form of a call argument or result the ReplayHostEnv decodes.
+func recordedValue(value interface{}) Bytes {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case codeBytes:
+		return Bytes(v)
+	case *[]byte:
+		if v == nil {
+			return nil
+		}
+		return append(Bytes{}, *v...)
+	case string:
+		return Bytes(v)
+	case lib.Address:
+		return append(Bytes{}, v[:]...)
+	case *big.Int:
+		return bigBytes(v)
+	case bool:
+		return boolBytes(v)
+	case uint64:
+		return u64Bytes(v)
+	case int64:
+		return u64Bytes(uint64(v))
+	case uint16:
+		return u64Bytes(uint64(v))
+	default:
+		return nil
+	}
+}
+
+// ReplayMismatch describes the first call which differs from the recording, Expected is nil if the
+// recording has no more calls and Actual is nil if recorded calls are left after the execution.
+type ReplayMismatch struct {
+	Index    int
+	Expected *RecordedCall
+	Actual   *RecordedCall
+}
+
+func (m *ReplayMismatch) Error() string {
+	switch {
+	case m.Expected == nil:
+		return fmt.Sprintf("replay call %v: unexpected %v, recording has no more calls", m.Index, m.Actual)
+	case m.Actual == nil:
+		return fmt.Sprintf("replay call %v: expected %v, execution has finished", m.Index, m.Expected)
+	case m.Expected.Method != m.Actual.Method || m.Expected.Depth != m.Actual.Depth:
+		return fmt.Sprintf("replay call %v: expected %v, got %v", m.Index, m.Expected, m.Actual)
+	default:
+		return fmt.Sprintf("replay call %v: %v is called with different arguments", m.Index, m.Actual)
+	}
+}
+
+// replayLog is the position in the recording shared by a ReplayHostEnv and its sub envs.
+type replayLog struct {
+	recording *Recording
+	next      int
+	err       *ReplayMismatch
+}
+
+// ReplayHostEnv is a lib.HostEnv serving responses from a Recording. Every call must match the recorded one
+// by method, depth and arguments, otherwise the env panics with a ReplayMismatch, so the execution fails
+// and Verify reports where the replay diverged. Recorded gas is consumed on the meter, so the replayed execution
+// uses the same gas. Errors are replayed by message.
+type ReplayHostEnv struct {
+	log   *replayLog
+	depth int
+}
+
+func NewReplayHostEnv(recording *Recording) *ReplayHostEnv {
+	return &ReplayHostEnv{log: &replayLog{recording: recording}}
+}
+
+// Verify returns the first mismatch or a mismatch for recorded calls which have not been replayed.
+func (e *ReplayHostEnv) Verify() error {
+	l := e.log
+	if l.err != nil {
+		return l.err
+	}
+	if l.next < len(l.recording.Calls) {
+		return &ReplayMismatch{Index: l.next, Expected: &l.recording.Calls[l.next]}
+	}
+	return nil
+}
+
+func (e *ReplayHostEnv) replay(meter *lib.GasMeter, method string, args ...[]byte) ([]byte, error) {
+	l := e.log
+	actual := &RecordedCall{Depth: e.depth, Method: method}
+	for _, arg := range args {
+		actual.Args = append(actual.Args, arg)
+	}
+	if l.err != nil {
+		panic(l.err)
+	}
+	if l.next >= len(l.recording.Calls) {
+		l.err = &ReplayMismatch{Index: l.next, Actual: actual}
+		panic(l.err)
+	}
+	expected := &l.recording.Calls[l.next]
+	if !sameCall(expected, actual) {
+		l.err = &ReplayMismatch{Index: l.next, Expected: expected, Actual: actual}
+		panic(l.err)
+	}
+	l.next++
+	if meter != nil {
+		meter.ConsumeGas(expected.GasUsed)
+	}
+	if expected.OutOfGas {
+		panic(lib.OutOfGas{})
+	}
+	if expected.Panic != "" {
+		panic(expected.Panic)
+	}
+	if expected.Error != "" {
+		return expected.Result, errors.New(expected.Error)
+	}
+	return expected.Result, nil
+}
+
+func sameCall(expected *RecordedCall, actual *RecordedCall) bool {
+	if expected.Method != actual.Method || expected.Depth != actual.Depth || len(expected.Args) != len(actual.Args) {
+		return false
+	}
+	for i := range expected.Args {
+		if !bytes.Equal(expected.Args[i], actual.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *ReplayHostEnv) replayAddress(meter *lib.GasMeter, method string, args ...[]byte) lib.Address {
+	res, _ := e.replay(meter, method, args...)
+	var addr lib.Address
+	copy(addr[:], res)
+	return addr
+}
+
+func (e *ReplayHostEnv) SetStorage(meter *lib.GasMeter, key []byte, value []byte) {
+	e.replay(meter, "SetStorage", key, value)
+}
+
+func (e *ReplayHostEnv) GetStorage(meter *lib.GasMeter, key []byte) []byte {
+	res, _ := e.replay(meter, "GetStorage", key)
+	return res
+}
+
+func (e *ReplayHostEnv) RemoveStorage(meter *lib.GasMeter, key []byte) {
+	e.replay(meter, "RemoveStorage", key)
+}
+
+func (e *ReplayHostEnv) BlockNumber(meter *lib.GasMeter) uint64 {
+	res, _ := e.replay(meter, "BlockNumber")
+	return bytesU64(res)
+}
+
+func (e *ReplayHostEnv) BlockTimestamp(meter *lib.GasMeter) int64 {
+	res, _ := e.replay(meter, "BlockTimestamp")
+	return int64(bytesU64(res))
+}
+
+func (e *ReplayHostEnv) MinFeePerGas(meter *lib.GasMeter) *big.Int {
+	res, _ := e.replay(meter, "MinFeePerGas")
+	return bytesBig(res)
+}
+
+func (e *ReplayHostEnv) Balance(meter *lib.GasMeter) *big.Int {
+	res, _ := e.replay(meter, "Balance")
+	return bytesBig(res)
+}
+
+func (e *ReplayHostEnv) BlockSeed(meter *lib.GasMeter) []byte {
+	res, _ := e.replay(meter, "BlockSeed")
+	return res
+}
+
+func (e *ReplayHostEnv) NetworkSize(meter *lib.GasMeter) uint64 {
+	res, _ := e.replay(meter, "NetworkSize")
+	return bytesU64(res)
+}
+
+func (e *ReplayHostEnv) Identity(meter *lib.GasMeter, address lib.Address) []byte {
+	res, _ := e.replay(meter, "Identity", address[:])
+	return res
+}
+
+func (e *ReplayHostEnv) CreateSubEnv(contract lib.Address, method string, payAmount *big.Int, isDeploy bool) (lib.HostEnv, error) {
+	if _, err := e.replay(nil, "CreateSubEnv", contract[:], []byte(method), bigBytes(payAmount), boolBytes(isDeploy)); err != nil {
+		return nil, err
+	}
+	return &ReplayHostEnv{log: e.log, depth: e.depth + 1}, nil
+}
+
+func (e *ReplayHostEnv) GetCode(addr lib.Address) []byte {
+	res, _ := e.replay(nil, "GetCode", addr[:])
+	return res
+}
+
+func (e *ReplayHostEnv) Commit() {
+	e.replay(nil, "Commit")
+}
+
+func (e *ReplayHostEnv) Revert() error {
+	_, err := e.replay(nil, "Revert")
+	return err
+}
+
+func (e *ReplayHostEnv) Caller(meter *lib.GasMeter) lib.Address {
+	return e.replayAddress(meter, "Caller")
+}
+
+func (e *ReplayHostEnv) OriginalCaller(meter *lib.GasMeter) lib.Address {
+	return e.replayAddress(meter, "OriginalCaller")
+}
+
+func (e *ReplayHostEnv) SubBalance(meter *lib.GasMeter, amount *big.Int) error {
+	_, err := e.replay(meter, "SubBalance", bigBytes(amount))
+	return err
+}
+
+func (e *ReplayHostEnv) AddBalance(meter *lib.GasMeter, address lib.Address, amount *big.Int) {
+	e.replay(meter, "AddBalance", address[:], bigBytes(amount))
+}
+
+func (e *ReplayHostEnv) ContractAddress(meter *lib.GasMeter) lib.Address {
+	return e.replayAddress(meter, "ContractAddress")
+}
+
+func (e *ReplayHostEnv) ContractAddr(meter *lib.GasMeter, code []byte, args []byte, nonce []byte) lib.Address {
+	return e.replayAddress(meter, "ContractAddr", code, args, nonce)
+}
+
+func (e *ReplayHostEnv) Deploy(code []byte) {
+	e.replay(nil, "Deploy", code)
+}
+
+func (e *ReplayHostEnv) ContractAddrByHash(meter *lib.GasMeter, hash []byte, args []byte, nonce []byte) lib.Address {
+	return e.replayAddress(meter, "ContractAddrByHash", hash, args, nonce)
+}
+
+func (e *ReplayHostEnv) OwnCode(meter *lib.GasMeter) []byte {
+	res, _ := e.replay(meter, "OwnCode")
+	return res
+}
+
+func (e *ReplayHostEnv) CodeHash(meter *lib.GasMeter) []byte {
+	res, _ := e.replay(meter, "CodeHash")
+	return res
+}
+
+func (e *ReplayHostEnv) Event(meter *lib.GasMeter, name string, args ...[]byte) {
+	e.replay(meter, "Event", append([][]byte{[]byte(name)}, args...)...)
+}
+
+func (e *ReplayHostEnv) ReadContractData(meter *lib.GasMeter, address lib.Address, key []byte) []byte {
+	res, _ := e.replay(meter, "ReadContractData", address[:], key)
+	return res
+}
+
+func (e *ReplayHostEnv) Epoch(meter *lib.GasMeter) uint16 {
+	res, _ := e.replay(meter, "Epoch")
+	return uint16(bytesU64(res))
+}
+
+func (e *ReplayHostEnv) ContractCodeHash(addr lib.Address) *[]byte {
+	res, _ := e.replay(nil, "ContractCodeHash", addr[:])
+	if res == nil {
+		return nil
+	}
+	return &res
+}
+
+func (e *ReplayHostEnv) PayAmount(meter *lib.GasMeter) *big.Int {
+	res, _ := e.replay(meter, "PayAmount")
+	return bytesBig(res)
+}
+
+func (e *ReplayHostEnv) IsDebug() bool {
+	return e.log.recording.Debug
+}
+
+func (e *ReplayHostEnv) BlockHeader(meter *lib.GasMeter, height uint64) []byte {
+	res, _ := e.replay(meter, "BlockHeader", u64Bytes(height))
+	return res
+}
+
+func (e *ReplayHostEnv) Keccak256(meter *lib.GasMeter, data []byte) []byte {
+	res, _ := e.replay(meter, "Keccak256", data)
+	return res
+}
+
+func (e *ReplayHostEnv) GlobalState(meter *lib.GasMeter) []byte {
+	res, _ := e.replay(meter, "GlobalState")
+	return res
+}
+
+func (e *ReplayHostEnv) Burn(meter *lib.GasMeter, amount *big.Int) error {
+	_, err := e.replay(meter, "Burn", bigBytes(amount))
+	return err
+}
+
+func (e *ReplayHostEnv) Ecrecover(meter *lib.GasMeter, data []byte, signature []byte) []byte {
+	res, _ := e.replay(meter, "Ecrecover", data, signature)
+	return res
+}
@@ -0,0 +1,584 @@
+package hostenv
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// Bytes is marshaled to JSON as a 0x-prefixed hex string.
+type Bytes []byte
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal("0x" + hex.EncodeToString(b))
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*b = nil
+		return nil
+	}
+	if !strings.HasPrefix(*s, "0x") {
+		return fmt.Errorf("hex string %q must have 0x prefix", *s)
+	}
+	res, err := hex.DecodeString((*s)[2:])
+	if err != nil {
+		return err
+	}
+	*b = append(Bytes{}, res...)
+	return nil
+}
+
+type TraceArg struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// TraceEntry describes a single HostEnv call. Callback and CallbackIndex refer to the innermost binding callback
+// running when the method is called, they are empty and -1 if the trace is not set as the callback tracer of the api
+// or the method is called outside of callbacks. GasUsed is the gas the method consumed on the meter.
+type TraceEntry struct {
+	Index         int         `json:"index"`
+	Depth         int         `json:"depth"`
+	Contract      Bytes       `json:"contract"`
+	Method        string      `json:"method"`
+	Callback      string      `json:"callback,omitempty"`
+	CallbackIndex int         `json:"callbackIndex"`
+	Args          []TraceArg  `json:"args,omitempty"`
+	Result        interface{} `json:"result,omitempty"`
+	Error         string      `json:"error,omitempty"`
+	Panic         string      `json:"panic,omitempty"`
+	GasUsed       uint64      `json:"gasUsed"`
+}
+
+// CallbackEntry describes a host callback handled by the binding. GasUsed is the gas the callback returned to the VM,
+// it includes the charge of the binding gas schedule and, for call and deploy, the gas of the nested execution.
+type CallbackEntry struct {
+	Index    int    `json:"index"`
+	Depth    int    `json:"depth"`
+	Contract Bytes  `json:"contract"`
+	Callback string `json:"callback"`
+	// Parent is the index of the call or deploy callback running the nested execution, -1 for top level callbacks
+	Parent  int    `json:"parent"`
+	GasUsed uint64 `json:"gasUsed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Trace collects entries of a TracingHostEnv and all its sub envs. It is a lib.CallbackTracer,
+// so set it to the api with SetCallbackTracer to trace callbacks along with the env calls they make.
+type Trace struct {
+	mu        sync.Mutex
+	entries   []TraceEntry
+	callbacks []CallbackEntry
+	// running holds indexes of callbacks which are started and not finished yet, the innermost is the last
+	running []int
+}
+
+func (t *Trace) addCall(call *hostCall) {
+	entry := TraceEntry{
+		Depth:    call.depth,
+		Contract: Bytes(call.contract[:]),
+		Method:   call.method,
+		Result:   traceValue(call.result),
+		GasUsed:  call.gasUsed,
+	}
+	for _, a := range call.args {
+		entry.Args = append(entry.Args, TraceArg{Name: a.Name, Value: traceValue(a.Value)})
+	}
+	if call.err != nil {
+		entry.Error = call.err.Error()
+	}
+	if call.panic != nil {
+		entry.Panic = fmt.Sprint(call.panic)
+	}
+	t.add(entry)
+}
+
+func (t *Trace) add(entry TraceEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry.Index = len(t.entries)
+	entry.CallbackIndex = -1
+	if len(t.running) > 0 {
+		entry.CallbackIndex = t.running[len(t.running)-1]
+		entry.Callback = t.callbacks[entry.CallbackIndex].Callback
+	}
+	t.entries = append(t.entries, entry)
+}
+
+func (t *Trace) CallbackStarted(fn lib.HostFunction, contract lib.Address, depth int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := CallbackEntry{
+		Index:    len(t.callbacks),
+		Depth:    depth,
+		Contract: Bytes(contract[:]),
+		Callback: "c" + string(fn),
+		Parent:   -1,
+	}
+	if len(t.running) > 0 {
+		entry.Parent = t.running[len(t.running)-1]
+	}
+	t.callbacks = append(t.callbacks, entry)
+	t.running = append(t.running, entry.Index)
+}
+
+func (t *Trace) CallbackFinished(fn lib.HostFunction, gasUsed uint64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.running) == 0 {
+		return
+	}
+	entry := &t.callbacks[t.running[len(t.running)-1]]
+	t.running = t.running[:len(t.running)-1]
+	entry.GasUsed = gasUsed
+	if err != nil {
+		entry.Error = err.Error()
+	}
+}
+
+func (t *Trace) Callbacks() []CallbackEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make([]CallbackEntry, len(t.callbacks))
+	copy(res, t.callbacks)
+	return res
+}
+
+func (t *Trace) Entries() []TraceEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make([]TraceEntry, len(t.entries))
+	copy(res, t.entries)
+	return res
+}
+
+func (t *Trace) JSON() ([]byte, error) {
+	return json.MarshalIndent(struct {
+		Callbacks []CallbackEntry `json:"callbacks"`
+		Entries   []TraceEntry    `json:"entries"`
+	}{t.Callbacks(), t.Entries()}, "", "  ")
+}
+
+// hostCall is a call made through a TracingHostEnv, arguments and the result keep their Go values.
+type hostCall struct {
+	depth    int
+	contract lib.Address
+	method   string
+	args     []TraceArg
+	result   interface{}
+	err      error
+	// panic is the value the call panicked with, the panic is propagated after the call is logged
+	panic   interface{}
+	gasUsed uint64
+}
+
+// callLog collects calls of a TracingHostEnv and all its sub envs, it is a Trace or a Recording.
+type callLog interface {
+	addCall(call *hostCall)
+}
+
+// codeBytes is contract code passed to or returned by the env, it is traced by its size.
+type codeBytes []byte
+
+// TracingHostEnv is a lib.HostEnv decorator recording every call made to the wrapped env.
+type TracingHostEnv struct {
+	env      lib.HostEnv
+	log      callLog
+	contract lib.Address
+	depth    int
+}
+
+func NewTracingHostEnv(env lib.HostEnv, contract lib.Address) *TracingHostEnv {
+	return &TracingHostEnv{
+		env:      env,
+		log:      &Trace{},
+		contract: contract,
+	}
+}
+
+// Trace returns the trace of the env, it is nil for the env of a RecordingHostEnv.
+func (e *TracingHostEnv) Trace() *Trace {
+	trace, _ := e.log.(*Trace)
+	return trace
+}
+
+func (e *TracingHostEnv) record(meter *lib.GasMeter, method string, args []TraceArg, fn func() (interface{}, error)) {
+	call := &hostCall{
+		depth:    e.depth,
+		contract: e.contract,
+		method:   method,
+		args:     args,
+	}
+	var gasBefore uint64
+	if meter != nil {
+		gasBefore = meter.GasConsumed()
+	}
+	defer func() {
+		if meter != nil {
+			call.gasUsed = meter.GasConsumed() - gasBefore
+		}
+		if rec := recover(); rec != nil {
+			call.panic = rec
+			e.log.addCall(call)
+			panic(rec)
+		}
+		e.log.addCall(call)
+	}()
+	call.result, call.err = fn()
+}
+
+func arg(name string, value interface{}) TraceArg {
+	return TraceArg{Name: name, Value: value}
+}
+
+func traceValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case codeBytes:
+		return len(v)
+	case []byte:
+		return Bytes(v)
+	case *[]byte:
+		if v == nil {
+			return nil
+		}
+		return Bytes(*v)
+	case [][]byte:
+		res := make([]Bytes, 0, len(v))
+		for _, b := range v {
+			res = append(res, b)
+		}
+		return res
+	case lib.Address:
+		return Bytes(v[:])
+	case *big.Int:
+		if v == nil {
+			return nil
+		}
+		return new(big.Int).Set(v)
+	default:
+		return v
+	}
+}
+
+func (e *TracingHostEnv) SetStorage(meter *lib.GasMeter, key []byte, value []byte) {
+	e.record(meter, "SetStorage", []TraceArg{arg("key", key), arg("value", value)}, func() (interface{}, error) {
+		e.env.SetStorage(meter, key, value)
+		return nil, nil
+	})
+}
+
+func (e *TracingHostEnv) GetStorage(meter *lib.GasMeter, key []byte) []byte {
+	var res []byte
+	e.record(meter, "GetStorage", []TraceArg{arg("key", key)}, func() (interface{}, error) {
+		res = e.env.GetStorage(meter, key)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) RemoveStorage(meter *lib.GasMeter, key []byte) {
+	e.record(meter, "RemoveStorage", []TraceArg{arg("key", key)}, func() (interface{}, error) {
+		e.env.RemoveStorage(meter, key)
+		return nil, nil
+	})
+}
+
+func (e *TracingHostEnv) BlockNumber(meter *lib.GasMeter) uint64 {
+	var res uint64
+	e.record(meter, "BlockNumber", nil, func() (interface{}, error) {
+		res = e.env.BlockNumber(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) BlockTimestamp(meter *lib.GasMeter) int64 {
+	var res int64
+	e.record(meter, "BlockTimestamp", nil, func() (interface{}, error) {
+		res = e.env.BlockTimestamp(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) MinFeePerGas(meter *lib.GasMeter) *big.Int {
+	var res *big.Int
+	e.record(meter, "MinFeePerGas", nil, func() (interface{}, error) {
+		res = e.env.MinFeePerGas(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Balance(meter *lib.GasMeter) *big.Int {
+	var res *big.Int
+	e.record(meter, "Balance", nil, func() (interface{}, error) {
+		res = e.env.Balance(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) BlockSeed(meter *lib.GasMeter) []byte {
+	var res []byte
+	e.record(meter, "BlockSeed", nil, func() (interface{}, error) {
+		res = e.env.BlockSeed(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) NetworkSize(meter *lib.GasMeter) uint64 {
+	var res uint64
+	e.record(meter, "NetworkSize", nil, func() (interface{}, error) {
+		res = e.env.NetworkSize(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Identity(meter *lib.GasMeter, address lib.Address) []byte {
+	var res []byte
+	e.record(meter, "Identity", []TraceArg{arg("address", address)}, func() (interface{}, error) {
+		res = e.env.Identity(meter, address)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) CreateSubEnv(contract lib.Address, method string, payAmount *big.Int, isDeploy bool) (lib.HostEnv, error) {
+	var res lib.HostEnv
+	var err error
+	args := []TraceArg{arg("contract", contract), arg("method", method), arg("payAmount", payAmount), arg("isDeploy", isDeploy)}
+	e.record(nil, "CreateSubEnv", args, func() (interface{}, error) {
+		var subEnv lib.HostEnv
+		if subEnv, err = e.env.CreateSubEnv(contract, method, payAmount, isDeploy); err != nil {
+			return nil, err
+		}
+		res = &TracingHostEnv{
+			env:      subEnv,
+			log:      e.log,
+			contract: contract,
+			depth:    e.depth + 1,
+		}
+		return nil, nil
+	})
+	return res, err
+}
+
+func (e *TracingHostEnv) GetCode(addr lib.Address) []byte {
+	var res []byte
+	e.record(nil, "GetCode", []TraceArg{arg("address", addr)}, func() (interface{}, error) {
+		res = e.env.GetCode(addr)
+		return codeBytes(res), nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Commit() {
+	e.record(nil, "Commit", nil, func() (interface{}, error) {
+		e.env.Commit()
+		return nil, nil
+	})
+}
+
+func (e *TracingHostEnv) Revert() error {
+	var err error
+	e.record(nil, "Revert", nil, func() (interface{}, error) {
+		err = revert(e.env)
+		return nil, err
+	})
+	return err
+}
+
+func (e *TracingHostEnv) Caller(meter *lib.GasMeter) lib.Address {
+	var res lib.Address
+	e.record(meter, "Caller", nil, func() (interface{}, error) {
+		res = e.env.Caller(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) OriginalCaller(meter *lib.GasMeter) lib.Address {
+	var res lib.Address
+	e.record(meter, "OriginalCaller", nil, func() (interface{}, error) {
+		res = e.env.OriginalCaller(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) SubBalance(meter *lib.GasMeter, amount *big.Int) error {
+	var res error
+	e.record(meter, "SubBalance", []TraceArg{arg("amount", amount)}, func() (interface{}, error) {
+		res = e.env.SubBalance(meter, amount)
+		return nil, res
+	})
+	return res
+}
+
+func (e *TracingHostEnv) AddBalance(meter *lib.GasMeter, address lib.Address, amount *big.Int) {
+	e.record(meter, "AddBalance", []TraceArg{arg("address", address), arg("amount", amount)}, func() (interface{}, error) {
+		e.env.AddBalance(meter, address, amount)
+		return nil, nil
+	})
+}
+
+func (e *TracingHostEnv) ContractAddress(meter *lib.GasMeter) lib.Address {
+	var res lib.Address
+	e.record(meter, "ContractAddress", nil, func() (interface{}, error) {
+		res = e.env.ContractAddress(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) ContractAddr(meter *lib.GasMeter, code []byte, args []byte, nonce []byte) lib.Address {
+	var res lib.Address
+	traceArgs := []TraceArg{arg("codeSize", codeBytes(code)), arg("args", args), arg("nonce", nonce)}
+	e.record(meter, "ContractAddr", traceArgs, func() (interface{}, error) {
+		res = e.env.ContractAddr(meter, code, args, nonce)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Deploy(code []byte) {
+	e.record(nil, "Deploy", []TraceArg{arg("codeSize", codeBytes(code))}, func() (interface{}, error) {
+		e.env.Deploy(code)
+		return nil, nil
+	})
+}
+
+func (e *TracingHostEnv) ContractAddrByHash(meter *lib.GasMeter, hash []byte, args []byte, nonce []byte) lib.Address {
+	var res lib.Address
+	traceArgs := []TraceArg{arg("hash", hash), arg("args", args), arg("nonce", nonce)}
+	e.record(meter, "ContractAddrByHash", traceArgs, func() (interface{}, error) {
+		res = e.env.ContractAddrByHash(meter, hash, args, nonce)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) OwnCode(meter *lib.GasMeter) []byte {
+	var res []byte
+	e.record(meter, "OwnCode", nil, func() (interface{}, error) {
+		res = e.env.OwnCode(meter)
+		return codeBytes(res), nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) CodeHash(meter *lib.GasMeter) []byte {
+	var res []byte
+	e.record(meter, "CodeHash", nil, func() (interface{}, error) {
+		res = e.env.CodeHash(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Event(meter *lib.GasMeter, name string, args ...[]byte) {
+	e.record(meter, "Event", []TraceArg{arg("name", name), arg("args", args)}, func() (interface{}, error) {
+		e.env.Event(meter, name, args...)
+		return nil, nil
+	})
+}
+
+func (e *TracingHostEnv) ReadContractData(meter *lib.GasMeter, address lib.Address, key []byte) []byte {
+	var res []byte
+	e.record(meter, "ReadContractData", []TraceArg{arg("address", address), arg("key", key)}, func() (interface{}, error) {
+		res = e.env.ReadContractData(meter, address, key)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Epoch(meter *lib.GasMeter) uint16 {
+	var res uint16
+	e.record(meter, "Epoch", nil, func() (interface{}, error) {
+		res = e.env.Epoch(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) ContractCodeHash(addr lib.Address) *[]byte {
+	var res *[]byte
+	e.record(nil, "ContractCodeHash", []TraceArg{arg("address", addr)}, func() (interface{}, error) {
+		res = e.env.ContractCodeHash(addr)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) PayAmount(meter *lib.GasMeter) *big.Int {
+	var res *big.Int
+	e.record(meter, "PayAmount", nil, func() (interface{}, error) {
+		res = e.env.PayAmount(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) IsDebug() bool {
+	return e.env.IsDebug()
+}
+
+func (e *TracingHostEnv) BlockHeader(meter *lib.GasMeter, height uint64) []byte {
+	var res []byte
+	e.record(meter, "BlockHeader", []TraceArg{arg("height", height)}, func() (interface{}, error) {
+		res = e.env.BlockHeader(meter, height)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Keccak256(meter *lib.GasMeter, data []byte) []byte {
+	var res []byte
+	e.record(meter, "Keccak256", []TraceArg{arg("data", data)}, func() (interface{}, error) {
+		res = e.env.Keccak256(meter, data)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) GlobalState(meter *lib.GasMeter) []byte {
+	var res []byte
+	e.record(meter, "GlobalState", nil, func() (interface{}, error) {
+		res = e.env.GlobalState(meter)
+		return res, nil
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Burn(meter *lib.GasMeter, amount *big.Int) error {
+	var res error
+	e.record(meter, "Burn", []TraceArg{arg("amount", amount)}, func() (interface{}, error) {
+		res = e.env.Burn(meter, amount)
+		return nil, res
+	})
+	return res
+}
+
+func (e *TracingHostEnv) Ecrecover(meter *lib.GasMeter, data []byte, signature []byte) []byte {
+	var res []byte
+	e.record(meter, "Ecrecover", []TraceArg{arg("data", data), arg("signature", signature)}, func() (interface{}, error) {
+		res = e.env.Ecrecover(meter, data, signature)
+		return res, nil
+	})
+	return res
+}
@@ -105,6 +105,8 @@ GoResult cecrecover(api_t *ptr,  U8SliceView data, U8SliceView sig,  uint64_t *u
 */
 import "C"
 import (
+	"context"
+	"fmt"
 	"github.com/golang/protobuf/proto"
 	models "github.com/idena-network/idena-wasm-binding/lib/protobuf"
 	"log"
@@ -121,6 +123,22 @@ const ActionReadIdentity = 5
 type GoAPI struct {
 	host     HostEnv
 	gasMeter *GasMeter
+	schedule *GasSchedule
+	forks    *ForkConfig
+	// rules are selected for each Execute and Deploy from the schedule and the fork config
+	rules    *Rules
+	ctx      context.Context
+	readOnly bool
+	contract Address
+	depth    int
+	profile  *GasProfile
+	tracer   CallbackTracer
+	events   *eventLog
+
+	// hostErr is set if the binding failed a callback of the api itself
+	hostErr error
+	// subCallErrs holds an entry for every call and deploy callback in the order of the callbacks, nil for successes
+	subCallErrs []error
 }
 
 func NewGoAPI(env HostEnv, gasMeter *GasMeter) *GoAPI {
@@ -130,6 +148,208 @@ func NewGoAPI(env HostEnv, gasMeter *GasMeter) *GoAPI {
 	}
 }
 
+// NewGoAPIWithGasSchedule returns an api charging host functions according to the schedule in addition to gas charged by the host.
+func NewGoAPIWithGasSchedule(env HostEnv, gasMeter *GasMeter, schedule *GasSchedule) *GoAPI {
+	api := NewGoAPI(env, gasMeter)
+	api.schedule = schedule
+	return api
+}
+
+func (api *GoAPI) IsDebug() bool {
+	return api.host.IsDebug()
+}
+
+// SetGasSchedule makes the binding charge host functions according to the schedule, nil schedule charges nothing.
+// The binding charges no gas by default, nested calls use the schedule of the root api.
+func (api *GoAPI) SetGasSchedule(schedule *GasSchedule) {
+	api.schedule = schedule
+}
+
+// SetForkConfig makes Execute and Deploy select the gas schedule and enabled host functions
+// by the block number and the epoch of the host, it overrides the schedule set by SetGasSchedule.
+func (api *GoAPI) SetForkConfig(forks *ForkConfig) {
+	api.forks = forks
+}
+
+func (api *GoAPI) applyForks() {
+	if api.forks == nil {
+		api.rules = &Rules{Schedule: api.schedule}
+		return
+	}
+	meter := &GasMeter{}
+	api.rules = api.forks.Rules(api.host.BlockNumber(meter), api.host.Epoch(meter))
+}
+
+func (api *GoAPI) GasSchedule() *GasSchedule {
+	return api.schedule
+}
+
+// SetReadOnly enables the static mode, an execution modifying state is aborted with ErrReadOnly.
+// The mode applies to nested calls too and hosts are never committed by the binding in the mode.
+func (api *GoAPI) SetReadOnly(readOnly bool) {
+	api.readOnly = readOnly
+}
+
+func (api *GoAPI) IsReadOnly() bool {
+	return api.readOnly
+}
+
+func (api *GoAPI) requireWritable() {
+	if api.readOnly {
+		panic(callbackFailure{C.GoResult_Other, ErrReadOnly})
+	}
+}
+
+// SetEventSink makes the binding deliver events emitted by contracts to the sink in addition to HostEnv.Event.
+// Event indexes start from zero in every Execute and Deploy.
+func (api *GoAPI) SetEventSink(sink EventSink) {
+	if sink == nil {
+		api.events = nil
+		return
+	}
+	api.events = &eventLog{sink: sink}
+}
+
+func (api *GoAPI) resetEvents() {
+	if api.events != nil {
+		api.events.reset()
+	}
+}
+
+func (api *GoAPI) eventIndex() int {
+	if api.events == nil {
+		return 0
+	}
+	return api.events.count
+}
+
+func (api *GoAPI) emitEvent(name string, args [][]byte) {
+	if api.events != nil {
+		api.events.emit(&ContractEvent{
+			Contract: api.contract,
+			Name:     name,
+			Args:     args,
+			Depth:    api.depth,
+		})
+	}
+}
+
+func (api *GoAPI) revertEvents(index int) {
+	if api.events != nil {
+		api.events.revert(index)
+	}
+}
+
+// SetGasProfile enables gas profiling of executions started with the api, nested calls share the profile.
+func (api *GoAPI) SetGasProfile(profile *GasProfile) {
+	api.profile = profile
+}
+
+func (api *GoAPI) GasProfile() *GasProfile {
+	return api.profile
+}
+
+// SetCallbackTracer makes the binding report host callbacks of executions started with the api to the tracer,
+// nested calls share the tracer.
+func (api *GoAPI) SetCallbackTracer(tracer CallbackTracer) {
+	api.tracer = tracer
+}
+
+func (api *GoAPI) subAPI(host HostEnv) *GoAPI {
+	return &GoAPI{
+		host:     host,
+		gasMeter: &GasMeter{},
+		rules:    api.rules,
+		ctx:      api.ctx,
+		readOnly: api.readOnly,
+		depth:    api.depth + 1,
+		profile:  api.profile,
+		tracer:   api.tracer,
+		events:   api.events,
+	}
+}
+
+// canceled returns the kind of the execution error if the execution context is done.
+func (api *GoAPI) canceled() error {
+	if api.ctx == nil {
+		return nil
+	}
+	if err := api.ctx.Err(); err != nil {
+		return canceledError{err}
+	}
+	return nil
+}
+
+// chargeGas charges base cost of the host function and per byte cost of its input and returns the charged gas,
+// nothing is charged without a gas schedule. It aborts the execution with GoResult_Other if the execution context is done or the host function
+// modifies state in read-only mode and fails the callback with GoResult_User if the host function is not enabled yet.
+func (api *GoAPI) chargeGas(fn HostFunction, inputSize int) uint64 {
+	if err := api.canceled(); err != nil {
+		panic(callbackFailure{C.GoResult_Other, err})
+	}
+	if fn.IsMutating() {
+		api.requireWritable()
+	}
+	if !api.rules.IsEnabled(fn) {
+		panic(callbackFailure{C.GoResult_User, ErrHostFunctionDisabled})
+	}
+	if api.rules.Schedule == nil {
+		return 0
+	}
+	gas := api.rules.Schedule.Cost(fn).Cost(inputSize)
+	api.gasMeter.ConsumeGas(gas)
+	return gas
+}
+
+// chargeOutput charges per byte cost of data returned by the host function.
+func (api *GoAPI) chargeOutput(fn HostFunction, outputSize int) {
+	if api.rules.Schedule != nil {
+		api.gasMeter.ConsumeGas(api.rules.Schedule.Cost(fn).PerByte * uint64(outputSize))
+	}
+}
+
+func (api *GoAPI) startCallback(fn HostFunction) {
+	if api.tracer != nil {
+		api.tracer.CallbackStarted(fn, api.contract, api.depth)
+	}
+}
+
+// finishCallback reports the callback to the tracer, err is the failure recovered from a panic if any.
+func (api *GoAPI) finishCallback(fn HostFunction, ret C.GoResult, gasUsed *cu64, err error) {
+	if api.tracer == nil {
+		return
+	}
+	if err == nil && ret != C.GoResult_Ok {
+		if (fn == HostCall || fn == HostDeploy) && len(api.subCallErrs) > 0 {
+			err = api.subCallErrs[len(api.subCallErrs)-1]
+		} else {
+			err = fmt.Errorf("%v failed", fn)
+		}
+	}
+	var gas uint64
+	if gasUsed != nil {
+		gas = uint64(*gasUsed)
+	}
+	api.tracer.CallbackFinished(fn, gas, err)
+}
+
+func (api *GoAPI) profileStorageKey(key []byte, gasUsed *cu64) {
+	if api.profile != nil {
+		api.profile.addStorageKey(key, uint64(*gasUsed))
+	}
+}
+
+func (api *GoAPI) profileSubCallCharge(gas uint64) {
+	if api.profile != nil {
+		api.profile.addSubCallCharge(api.contract, gas)
+	}
+}
+
+func (api *GoAPI) resetErrors() {
+	api.hostErr = nil
+	api.subCallErrs = nil
+}
+
 var api_vtable = C.GoApi_vtable{
 	set_remaining_gas:     (C.set_remaining_gas_fn)(C.cset_remaining_gas),
 	set_storage:           (C.set_storage_fn)(C.cset_storage),
@@ -174,19 +394,49 @@ func buildAPI(api *GoAPI) C.GoApi {
 	}
 }
 
-func recoverPanicAndResetGasUsed(ret *C.GoResult, api *GoAPI, gasUsed *cu64) {
+// callbackFailure is raised by the binding to fail a callback with the result, err becomes the kind of the execution error.
+type callbackFailure struct {
+	result C.GoResult
+	err    error
+}
+
+// goResultUser is C.GoResult_User, which cannot be used in test files directly
+const goResultUser C.GoResult = C.GoResult_User
+
+func recoverPanicAndResetGasUsed(ret *C.GoResult, api *GoAPI, fn HostFunction, gasUsed *cu64) {
+	var err error
 	if rec := recover(); rec != nil {
-		switch rec.(type) {
+		switch r := rec.(type) {
 		case OutOfGas:
 			*ret = C.GoResult_OutOfGas
 			if gasUsed != nil {
 				*gasUsed = cu64(api.gasMeter.gasLimit)
 			}
+			err = ErrOutOfGas
+		case callbackFailure:
+			*ret = r.result
+			if gasUsed != nil {
+				*gasUsed = 0
+			}
+			api.hostErr = r.err
+			err = r.err
 		default:
 			log.Printf("Panic in Go callback: %#v\n", rec)
+			api.hostErr = ErrHostPanic
 			*ret = C.GoResult_Panic
+			err = ErrHostPanic
 		}
 	}
+	// call and deploy append their error last, so a failure recovered here has not been appended yet
+	if err != nil && fn.IsSubCall() {
+		api.subCallErrs = append(api.subCallErrs, err)
+	}
+	if api.profile != nil && gasUsed != nil {
+		api.profile.addHostFunction(api.contract, fn, uint64(*gasUsed))
+	}
+	if fn != "" {
+		api.finishCallback(fn, *ret, gasUsed, err)
+	}
 	api.gasMeter.gasLimit = 0
 	api.gasMeter.gasConsumed = 0
 }
@@ -194,7 +444,7 @@ func recoverPanicAndResetGasUsed(ret *C.GoResult, api *GoAPI, gasUsed *cu64) {
 //export cset_remaining_gas
 func cset_remaining_gas(ptr *C.api_t, remainingGas cu64) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, nil)
+	defer recoverPanicAndResetGasUsed(&ret, api, "", nil)
 	api.gasMeter.SetRemainingGas(uint64(remainingGas))
 	return C.GoResult_Ok
 }
@@ -202,25 +452,32 @@ func cset_remaining_gas(ptr *C.api_t, remainingGas cu64) (ret C.GoResult) {
 //export cset_storage
 func cset_storage(ptr *C.api_t, key C.U8SliceView, value C.U8SliceView, gasUsed *cu64) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostSetStorage)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostSetStorage, gasUsed)
 
 	k := copyU8Slice(key)
 	v := copyU8Slice(value)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostSetStorage, len(k)+len(v))
 	api.host.SetStorage(api.gasMeter, k, v)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
+	api.profileStorageKey(k, gasUsed)
 	return C.GoResult_Ok
 }
 
 //export cget_storage
 func cget_storage(ptr *C.api_t, key C.U8SliceView, gasUsed *cu64, value *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostGetStorage)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostGetStorage, gasUsed)
 
 	k := copyU8Slice(key)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostGetStorage, len(k))
 	v := api.host.GetStorage(api.gasMeter, k)
+	api.chargeOutput(HostGetStorage, len(v))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
+	api.profileStorageKey(k, gasUsed)
 	*value = newUnmanagedVector(v)
 	return C.GoResult_Ok
 }
@@ -228,12 +485,15 @@ func cget_storage(ptr *C.api_t, key C.U8SliceView, gasUsed *cu64, value *C.Unman
 //export cremove_storage
 func cremove_storage(ptr *C.api_t, key C.U8SliceView, gasUsed *cu64) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostRemoveStorage)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostRemoveStorage, gasUsed)
 
 	k := copyU8Slice(key)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostRemoveStorage, len(k))
 	api.host.RemoveStorage(api.gasMeter, k)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
+	api.profileStorageKey(k, gasUsed)
 	return C.GoResult_Ok
 }
 
@@ -241,9 +501,11 @@ func cremove_storage(ptr *C.api_t, key C.U8SliceView, gasUsed *cu64) (ret C.GoRe
 func cblock_timestamp(ptr *C.api_t, gasUsed *cu64, blockTimestamp *ci64) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostBlockTimestamp)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostBlockTimestamp, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostBlockTimestamp, 0)
 
 	*blockTimestamp = ci64(api.host.BlockTimestamp(api.gasMeter))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
@@ -254,8 +516,10 @@ func cblock_timestamp(ptr *C.api_t, gasUsed *cu64, blockTimestamp *ci64) (ret C.
 func cblock_number(ptr *C.api_t, gasUsed *cu64, blockNumer *cu64) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostBlockNumber)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostBlockNumber, gasUsed)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostBlockNumber, 0)
 
 	*blockNumer = cu64(api.host.BlockNumber(api.gasMeter))
 
@@ -267,9 +531,11 @@ func cblock_number(ptr *C.api_t, gasUsed *cu64, blockNumer *cu64) (ret C.GoResul
 func cmin_fee_per_gas(ptr *C.api_t, gasUsed *cu64, data *C.UnmanagedVector) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostMinFeePerGas)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostMinFeePerGas, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostMinFeePerGas, 0)
 	feePerGas := api.host.MinFeePerGas(api.gasMeter)
 	*data = newUnmanagedVector(feePerGas.Bytes())
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
@@ -280,9 +546,11 @@ func cmin_fee_per_gas(ptr *C.api_t, gasUsed *cu64, data *C.UnmanagedVector) (ret
 func cbalance(ptr *C.api_t, gasUsed *cu64, data *C.UnmanagedVector) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostBalance)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostBalance, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostBalance, 0)
 	balance := api.host.Balance(api.gasMeter)
 
 	*data = newUnmanagedVector(balance.Bytes())
@@ -294,9 +562,11 @@ func cbalance(ptr *C.api_t, gasUsed *cu64, data *C.UnmanagedVector) (ret C.GoRes
 func cblock_seed(ptr *C.api_t, gasUsed *cu64, data *C.UnmanagedVector) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostBlockSeed)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostBlockSeed, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostBlockSeed, 0)
 	seed := api.host.BlockSeed(api.gasMeter)
 	*data = newUnmanagedVector(seed)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
@@ -307,9 +577,11 @@ func cblock_seed(ptr *C.api_t, gasUsed *cu64, data *C.UnmanagedVector) (ret C.Go
 func cnetwork_size(ptr *C.api_t, gasUsed *cu64, network *cu64) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostNetworkSize)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostNetworkSize, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostNetworkSize, 0)
 
 	*network = cu64(api.host.NetworkSize(api.gasMeter))
 
@@ -320,13 +592,17 @@ func cnetwork_size(ptr *C.api_t, gasUsed *cu64, network *cu64) (ret C.GoResult)
 //export cidentity
 func cidentity(ptr *C.api_t, addr C.U8SliceView, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostIdentity)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostIdentity, gasUsed)
 
 	address := newAddress(copyU8Slice(addr))
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostIdentity, 0)
 
-	*result = newUnmanagedVector(api.host.Identity(api.gasMeter, address))
+	identity := api.host.Identity(api.gasMeter, address)
+	*result = newUnmanagedVector(identity)
 
+	api.chargeOutput(HostIdentity, len(identity))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	return C.GoResult_Ok
 }
@@ -335,14 +611,15 @@ func cidentity(ptr *C.api_t, addr C.U8SliceView, gasUsed *cu64, result *C.Unmana
 func ccall(ptr *C.api_t, addr C.U8SliceView, method C.U8SliceView, args C.U8SliceView, amount C.U8SliceView, invocationContext C.U8SliceView, gasLimit cu64, gasUsed *cu64, actionResult *C.UnmanagedVector) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostCall)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostCall, gasUsed)
 	address := newAddress(copyU8Slice(addr))
-
-	code := api.host.GetCode(address)
 	pAmount := copyU8Slice(amount)
 	pArgs := copyU8Slice(args)
 	pMethod := copyU8Slice(method)
 
+	payAmount := big.NewInt(0).SetBytes(pAmount)
+
 	setActionResult := func(err string) {
 		actionResultObj := models.ActionResult{}
 		actionResultObj.Contract = address[:]
@@ -362,27 +639,47 @@ func ccall(ptr *C.api_t, addr C.U8SliceView, method C.U8SliceView, args C.U8Slic
 		}
 	}
 
+	if err := api.canceled(); err != nil {
+		api.subCallErrs = append(api.subCallErrs, err)
+		setActionResult(err.Error())
+		return C.GoResult_Other
+	}
+	if payAmount.Sign() > 0 && api.readOnly {
+		api.subCallErrs = append(api.subCallErrs, ErrReadOnly)
+		setActionResult(ErrReadOnly.Error())
+		return C.GoResult_Other
+	}
+
+	charged := api.chargeGas(HostCall, len(pMethod)+len(pArgs))
+	api.profileSubCallCharge(charged)
+	code := api.host.GetCode(address)
 	if len(code) == 0 {
-		setActionResult("code is empty")
+		api.subCallErrs = append(api.subCallErrs, ErrCodeEmpty)
+		setActionResult(ErrCodeEmpty.Error())
+		*gasUsed = cu64(charged)
 		return C.GoResult_Other
 	}
 
-	subHost, err := api.host.CreateSubEnv(address, string(pMethod), big.NewInt(0).SetBytes(pAmount), false)
+	subHost, err := api.host.CreateSubEnv(address, string(pMethod), payAmount, false)
 	if err != nil {
+		api.subCallErrs = append(api.subCallErrs, ErrSubCallRejected)
 		setActionResult(err.Error())
+		*gasUsed = cu64(charged)
 		return C.GoResult_Other
 	}
-	meter := GasMeter{}
-	subApi := &GoAPI{
-		host:     subHost,
-		gasMeter: &meter,
-	}
+	subApi := api.subAPI(subHost)
+	eventIndex := api.eventIndex()
 	subCallGasUsed, actionResultBytes, err := execute(subApi, code, pMethod, pArgs, copyU8Slice(invocationContext), address, uint64(gasLimit), subHost.IsDebug())
-	if err == nil {
+	if err == nil && !api.readOnly {
 		subHost.Commit()
 		api.host.Commit()
 	}
-	*gasUsed = cu64(subCallGasUsed)
+	if err != nil {
+		revertSubEnv(subHost)
+		api.revertEvents(eventIndex)
+	}
+	api.subCallErrs = append(api.subCallErrs, err)
+	*gasUsed = cu64(charged + subCallGasUsed)
 	*actionResult = newUnmanagedVector(actionResultBytes)
 	if err != nil {
 		return C.GoResult_Other
@@ -394,7 +691,8 @@ func ccall(ptr *C.api_t, addr C.U8SliceView, method C.U8SliceView, args C.U8Slic
 func cdeploy(ptr *C.api_t, code C.U8SliceView, args C.U8SliceView, nonce C.U8SliceView, amount C.U8SliceView, gasLimit cu64, gasUsed *cu64, actionResult *C.UnmanagedVector) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostDeploy)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostDeploy, gasUsed)
 	pNonce := copyU8Slice(nonce)
 	pAmount := copyU8Slice(amount)
 	pArgs := copyU8Slice(args)
@@ -423,28 +721,47 @@ func cdeploy(ptr *C.api_t, code C.U8SliceView, args C.U8SliceView, nonce C.U8Sli
 		}
 	}
 
+	if err := api.canceled(); err != nil {
+		api.subCallErrs = append(api.subCallErrs, err)
+		setActionResult(err.Error())
+		return C.GoResult_Other
+	}
+	if api.readOnly {
+		api.subCallErrs = append(api.subCallErrs, ErrReadOnly)
+		setActionResult(ErrReadOnly.Error())
+		return C.GoResult_Other
+	}
+
+	charged := api.chargeGas(HostDeploy, len(pCode)+len(pArgs))
+	api.profileSubCallCharge(charged)
 	if api.host.ContractCodeHash(addr) != nil {
-		setActionResult("contract is already deployed")
+		api.subCallErrs = append(api.subCallErrs, ErrAlreadyDeployed)
+		setActionResult(ErrAlreadyDeployed.Error())
+		*gasUsed = cu64(charged)
 		return C.GoResult_Other
 	}
 
 	subHost, err := api.host.CreateSubEnv(addr, "deploy", big.NewInt(0).SetBytes(copyU8Slice(amount)), true)
 	if err != nil {
+		api.subCallErrs = append(api.subCallErrs, ErrSubCallRejected)
 		setActionResult(err.Error())
+		*gasUsed = cu64(charged)
 		return C.GoResult_Other
 	}
-	meter := GasMeter{}
-	subApi := &GoAPI{
-		host:     subHost,
-		gasMeter: &meter,
-	}
+	subApi := api.subAPI(subHost)
 	subHost.Deploy(pCode)
+	eventIndex := api.eventIndex()
 	subCallGasUsed, actionResultBytes, err := deploy(subApi, pCode, pArgs, addr, uint64(gasLimit), subHost.IsDebug())
-	if err == nil {
+	if err == nil && !api.readOnly {
 		subHost.Commit()
 		api.host.Commit()
 	}
-	*gasUsed = cu64(subCallGasUsed)
+	if err != nil {
+		revertSubEnv(subHost)
+		api.revertEvents(eventIndex)
+	}
+	api.subCallErrs = append(api.subCallErrs, err)
+	*gasUsed = cu64(charged + subCallGasUsed)
 	*actionResult = newUnmanagedVector(actionResultBytes)
 	if err != nil {
 		return C.GoResult_Other
@@ -455,9 +772,11 @@ func cdeploy(ptr *C.api_t, code C.U8SliceView, args C.U8SliceView, nonce C.U8Sli
 //export ccaller
 func ccaller(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostCaller)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostCaller, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostCaller, 0)
 
 	addr := api.host.Caller(api.gasMeter)
 	*result = newUnmanagedVector(addr[:])
@@ -470,9 +789,11 @@ func ccaller(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoRe
 func coriginal_caller(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostOriginalCaller)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostOriginalCaller, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostOriginalCaller, 0)
 
 	addr := api.host.OriginalCaller(api.gasMeter)
 	*result = newUnmanagedVector(addr[:])
@@ -485,10 +806,12 @@ func coriginal_caller(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (r
 func cdeduct_balance(ptr *C.api_t, amount C.U8SliceView, gasUsed *cu64, errOut *C.UnmanagedVector) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostDeductBalance)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostDeductBalance, gasUsed)
 
 	amountBytes := copyU8Slice(amount)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostDeductBalance, 0)
 
 	if err := api.host.SubBalance(api.gasMeter, big.NewInt(0).SetBytes(amountBytes)); err != nil {
 		*errOut = newUnmanagedVector([]byte(err.Error()))
@@ -505,11 +828,13 @@ func cdeduct_balance(ptr *C.api_t, amount C.U8SliceView, gasUsed *cu64, errOut *
 func cadd_balance(ptr *C.api_t, addr C.U8SliceView, amount C.U8SliceView, gasUsed *cu64) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostAddBalance)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostAddBalance, gasUsed)
 
 	address := newAddress(copyU8Slice(addr))
 	amountBytes := copyU8Slice(amount)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostAddBalance, 0)
 	api.host.AddBalance(api.gasMeter, address, big.NewInt(0).SetBytes(amountBytes))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 
@@ -519,9 +844,11 @@ func cadd_balance(ptr *C.api_t, addr C.U8SliceView, amount C.U8SliceView, gasUse
 //export ccontract
 func ccontract(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostContract)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostContract, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostContract, 0)
 	addr := api.host.ContractAddress(api.gasMeter)
 	*result = newUnmanagedVector(addr[:])
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
@@ -532,12 +859,14 @@ func ccontract(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.Go
 //export ccontract_addr
 func ccontract_addr(ptr *C.api_t, code C.U8SliceView, args C.U8SliceView, nonce C.U8SliceView, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostContractAddr)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostContractAddr, gasUsed)
 	codeBytes := copyU8Slice(code)
 	argsBytes := copyU8Slice(args)
 	nonceBytes := copyU8Slice(nonce)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostContractAddr, len(codeBytes)+len(argsBytes)+len(nonceBytes))
 
 	address := api.host.ContractAddr(api.gasMeter, codeBytes, argsBytes, nonceBytes)
 	*result = newUnmanagedVector(address[:])
@@ -549,12 +878,14 @@ func ccontract_addr(ptr *C.api_t, code C.U8SliceView, args C.U8SliceView, nonce
 //export ccontract_addr_by_hash
 func ccontract_addr_by_hash(ptr *C.api_t, hash C.U8SliceView, args C.U8SliceView, nonce C.U8SliceView, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostContractAddrByHash)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostContractAddrByHash, gasUsed)
 	codeBytes := copyU8Slice(hash)
 	argsBytes := copyU8Slice(args)
 	nonceBytes := copyU8Slice(nonce)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostContractAddrByHash, len(argsBytes)+len(nonceBytes))
 
 	address := api.host.ContractAddrByHash(api.gasMeter, codeBytes, argsBytes, nonceBytes)
 	*result = newUnmanagedVector(address[:])
@@ -566,11 +897,14 @@ func ccontract_addr_by_hash(ptr *C.api_t, hash C.U8SliceView, args C.U8SliceView
 //export cown_code
 func cown_code(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostOwnCode)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostOwnCode, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostOwnCode, 0)
 	code := api.host.OwnCode(api.gasMeter)
 	*result = newUnmanagedVector(code)
+	api.chargeOutput(HostOwnCode, len(code))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 
 	return C.GoResult_Ok
@@ -579,9 +913,11 @@ func cown_code(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.Go
 //export ccode_hash
 func ccode_hash(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostCodeHash)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostCodeHash, gasUsed)
 
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostCodeHash, 0)
 	code := api.host.CodeHash(api.gasMeter)
 	*result = newUnmanagedVector(code)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
@@ -592,9 +928,15 @@ func ccode_hash(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.G
 //export cevent
 func cevent(ptr *C.api_t, eventName C.U8SliceView, args C.U8SliceView, gasUsed *cu64) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostEvent)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostEvent, gasUsed)
+	name := copyU8Slice(eventName)
+	eventArgs := copyU8Slice(args)
 	gasBefore := api.gasMeter.GasConsumed()
-	api.host.Event(api.gasMeter, string(copyU8Slice(eventName)), UnpackArguments(copyU8Slice(args))...)
+	api.chargeGas(HostEvent, len(name)+len(eventArgs))
+	unpackedArgs := UnpackArguments(eventArgs)
+	api.host.Event(api.gasMeter, string(name), unpackedArgs...)
+	api.emitEvent(string(name), unpackedArgs)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	return C.GoResult_Ok
 }
@@ -602,20 +944,27 @@ func cevent(ptr *C.api_t, eventName C.U8SliceView, args C.U8SliceView, gasUsed *
 //export cread_contract_data
 func cread_contract_data(ptr *C.api_t, addr C.U8SliceView, key C.U8SliceView, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostReadContractData)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostReadContractData, gasUsed)
 	address := newAddress(copyU8Slice(addr))
+	k := copyU8Slice(key)
 	gasBefore := api.gasMeter.GasConsumed()
-	value := api.host.ReadContractData(api.gasMeter, address, copyU8Slice(key))
+	api.chargeGas(HostReadContractData, len(k))
+	value := api.host.ReadContractData(api.gasMeter, address, k)
 	*result = newUnmanagedVector(value)
+	api.chargeOutput(HostReadContractData, len(value))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
+	api.profileStorageKey(k, gasUsed)
 	return C.GoResult_Ok
 }
 
 //export cepoch
 func cepoch(ptr *C.api_t, gasUsed *cu64, epoch *cu16) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostEpoch)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostEpoch, gasUsed)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostEpoch, 0)
 	e := api.host.Epoch(api.gasMeter)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	*epoch = cu16(e)
@@ -625,8 +974,10 @@ func cepoch(ptr *C.api_t, gasUsed *cu64, epoch *cu16) (ret C.GoResult) {
 //export cpay_amount
 func cpay_amount(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostPayAmount)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostPayAmount, gasUsed)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostPayAmount, 0)
 	amount := api.host.PayAmount(api.gasMeter)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	*result = newUnmanagedVector(amount.Bytes())
@@ -636,9 +987,12 @@ func cpay_amount(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.
 //export cblock_header
 func cblock_header(ptr *C.api_t, height cu64, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostBlockHeader)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostBlockHeader, gasUsed)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostBlockHeader, 0)
 	data := api.host.BlockHeader(api.gasMeter, uint64(height))
+	api.chargeOutput(HostBlockHeader, len(data))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	*result = newUnmanagedVector(data)
 	return C.GoResult_Ok
@@ -647,9 +1001,12 @@ func cblock_header(ptr *C.api_t, height cu64, gasUsed *cu64, result *C.Unmanaged
 //export ckeccak256
 func ckeccak256(ptr *C.api_t, data C.U8SliceView, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostKeccak256)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostKeccak256, gasUsed)
+	input := copyU8Slice(data)
 	gasBefore := api.gasMeter.GasConsumed()
-	hash := api.host.Keccak256(api.gasMeter, copyU8Slice(data))
+	api.chargeGas(HostKeccak256, len(input))
+	hash := api.host.Keccak256(api.gasMeter, input)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	*result = newUnmanagedVector(hash)
 	return C.GoResult_Ok
@@ -658,9 +1015,12 @@ func ckeccak256(ptr *C.api_t, data C.U8SliceView, gasUsed *cu64, result *C.Unman
 //export cglobal_state
 func cglobal_state(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostGlobalState)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostGlobalState, gasUsed)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostGlobalState, 0)
 	data := api.host.GlobalState(api.gasMeter)
+	api.chargeOutput(HostGlobalState, len(data))
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	*result = newUnmanagedVector(data)
 	return C.GoResult_Ok
@@ -670,10 +1030,12 @@ func cglobal_state(ptr *C.api_t, gasUsed *cu64, result *C.UnmanagedVector) (ret
 func cburn(ptr *C.api_t, amount C.U8SliceView, gasUsed *cu64) (ret C.GoResult) {
 
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostBurn)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostBurn, gasUsed)
 
 	amountBytes := copyU8Slice(amount)
 	gasBefore := api.gasMeter.GasConsumed()
+	api.chargeGas(HostBurn, 0)
 
 	if err := api.host.Burn(api.gasMeter, big.NewInt(0).SetBytes(amountBytes)); err != nil {
 		*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
@@ -688,9 +1050,13 @@ func cburn(ptr *C.api_t, amount C.U8SliceView, gasUsed *cu64) (ret C.GoResult) {
 //export cecrecover
 func cecrecover(ptr *C.api_t, data C.U8SliceView, sig C.U8SliceView, gasUsed *cu64, pubkey *C.UnmanagedVector) (ret C.GoResult) {
 	api := (*GoAPI)(unsafe.Pointer(ptr))
-	defer recoverPanicAndResetGasUsed(&ret, api, gasUsed)
+	api.startCallback(HostEcrecover)
+	defer recoverPanicAndResetGasUsed(&ret, api, HostEcrecover, gasUsed)
+	input := copyU8Slice(data)
+	signature := copyU8Slice(sig)
 	gasBefore := api.gasMeter.GasConsumed()
-	pb := api.host.Ecrecover(api.gasMeter, copyU8Slice(data), copyU8Slice(sig))
+	api.chargeGas(HostEcrecover, len(input)+len(signature))
+	pb := api.host.Ecrecover(api.gasMeter, input, signature)
 	*gasUsed = cu64(api.gasMeter.GasConsumed() - gasBefore)
 	*pubkey = newUnmanagedVector(pb)
 	return C.GoResult_Ok
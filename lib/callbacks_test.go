@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"github.com/golang/protobuf/proto"
+	models "github.com/idena-network/idena-wasm-binding/lib/protobuf"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+// testHost is a HostEnv with a deployed contract at every address, it records mutations and commits.
+type testHost struct {
+	HostEnv
+	height  uint64
+	storage map[string][]byte
+	events  []string
+	commits int
+}
+
+func newTestHost() *testHost {
+	return &testHost{storage: map[string][]byte{}}
+}
+
+func (h *testHost) BlockNumber(meter *GasMeter) uint64 {
+	return h.height
+}
+
+func (h *testHost) Epoch(meter *GasMeter) uint16 {
+	return 0
+}
+
+func (h *testHost) SetStorage(meter *GasMeter, key []byte, value []byte) {
+	h.storage[string(key)] = value
+}
+
+func (h *testHost) Event(meter *GasMeter, name string, args ...[]byte) {
+	h.events = append(h.events, name)
+}
+
+func (h *testHost) GetCode(addr Address) []byte {
+	return []byte{0x0, 0x61, 0x73, 0x6d}
+}
+
+func (h *testHost) ContractAddr(meter *GasMeter, code []byte, args []byte, nonce []byte) Address {
+	return Address{0x2}
+}
+
+func (h *testHost) ContractCodeHash(addr Address) *[]byte {
+	return nil
+}
+
+func (h *testHost) CreateSubEnv(contract Address, method string, payAmount *big.Int, isDeploy bool) (HostEnv, error) {
+	return nil, ErrSubCallRejected
+}
+
+func (h *testHost) Commit() {
+	h.commits++
+}
+
+func callActionError(t *testing.T, data []byte) string {
+	actionResult := models.ActionResult{}
+	require.NoError(t, proto.Unmarshal(data, &actionResult))
+	require.False(t, actionResult.Success)
+	return actionResult.Error
+}
+
+func TestReadOnlyCallbacks(t *testing.T) {
+	host := newTestHost()
+	api := NewGoAPIWithGasSchedule(host, &GasMeter{}, DefaultGasSchedule())
+	api.SetReadOnly(true)
+	api.applyForks()
+	state := buildAPI(api).state
+	var gasUsed cu64
+
+	require.NotZero(t, cset_storage(state, constructU8SliceView([]byte("k")), constructU8SliceView([]byte("v")), &gasUsed))
+	require.Equal(t, ErrReadOnly, api.hostErr)
+	require.Empty(t, host.storage)
+
+	api.hostErr = nil
+	require.NotZero(t, cevent(state, constructU8SliceView([]byte("emit")), constructU8SliceView(PackArguments(nil)), &gasUsed))
+	require.Equal(t, ErrReadOnly, api.hostErr)
+	require.Empty(t, host.events)
+
+	// nested calls inherit the mode
+	sub := api.subAPI(host)
+	require.True(t, sub.IsReadOnly())
+	require.NotZero(t, cset_storage(buildAPI(sub).state, constructU8SliceView([]byte("k")), constructU8SliceView([]byte("v")), &gasUsed))
+	require.Equal(t, ErrReadOnly, sub.hostErr)
+	require.Empty(t, host.storage)
+
+	// a call with a value and any deploy are rejected before the call is charged
+	gasUsed = 0
+	actionResult := newUnmanagedVector(nil)
+	addr := Address{0x2}
+	require.NotZero(t, ccall(state, constructU8SliceView(addr[:]), constructU8SliceView([]byte("inc")), constructU8SliceView(nil),
+		constructU8SliceView([]byte{0x1}), constructU8SliceView(nil), 1000, &gasUsed, &actionResult))
+	require.Zero(t, gasUsed)
+	require.Equal(t, ErrReadOnly.Error(), callActionError(t, copyAndDestroyUnmanagedVector(actionResult)))
+
+	actionResult = newUnmanagedVector(nil)
+	require.NotZero(t, cdeploy(state, constructU8SliceView([]byte{0x0, 0x61, 0x73, 0x6d}), constructU8SliceView(nil), constructU8SliceView(nil),
+		constructU8SliceView(nil), 1000, &gasUsed, &actionResult))
+	require.Zero(t, gasUsed)
+	require.Equal(t, ErrReadOnly.Error(), callActionError(t, copyAndDestroyUnmanagedVector(actionResult)))
+	require.Equal(t, []error{ErrReadOnly, ErrReadOnly}, api.subCallErrs)
+	require.Zero(t, host.commits)
+
+	// the same call is charged once it passes the mode check
+	api.SetReadOnly(false)
+	api.subCallErrs = nil
+	actionResult = newUnmanagedVector(nil)
+	require.NotZero(t, ccall(state, constructU8SliceView(addr[:]), constructU8SliceView([]byte("inc")), constructU8SliceView(nil),
+		constructU8SliceView([]byte{0x1}), constructU8SliceView(nil), 1000, &gasUsed, &actionResult))
+	require.Equal(t, cu64(DefaultGasSchedule().Cost(HostCall).Cost(len("inc"))), gasUsed)
+	require.Equal(t, []error{ErrSubCallRejected}, api.subCallErrs)
+	require.Zero(t, host.commits)
+}
+
+func TestForkDisabledCallback(t *testing.T) {
+	host := newTestHost()
+	host.height = 5
+	api := NewGoAPIWithGasSchedule(host, &GasMeter{}, DefaultGasSchedule())
+	api.SetForkConfig(&ForkConfig{
+		Schedule: DefaultGasSchedule(),
+		Forks:    []Fork{{Name: "storage", Height: 10, Enable: []HostFunction{HostSetStorage}}},
+	})
+	api.applyForks()
+	state := buildAPI(api).state
+	var gasUsed cu64
+
+	// the error is fed back to the contract and nothing is charged
+	require.Equal(t, goResultUser, cset_storage(state, constructU8SliceView([]byte("k")), constructU8SliceView([]byte("v")), &gasUsed))
+	require.Equal(t, ErrHostFunctionDisabled, api.hostErr)
+	require.Zero(t, gasUsed)
+	require.Zero(t, api.gasMeter.GasConsumed())
+	require.Empty(t, host.storage)
+
+	// functions not listed by forks stay available
+	var number cu64
+	require.Zero(t, cblock_number(state, &gasUsed, &number))
+	require.Equal(t, cu64(5), number)
+
+	host.height = 10
+	api.hostErr = nil
+	api.applyForks()
+	require.Zero(t, cset_storage(state, constructU8SliceView([]byte("k")), constructU8SliceView([]byte("v")), &gasUsed))
+	require.NoError(t, api.hostErr)
+	require.Equal(t, []byte("v"), host.storage["k"])
+}
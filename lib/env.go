@@ -49,6 +49,20 @@ type HostEnv interface {
 	Ecrecover(meter *GasMeter, data []byte, signature []byte) []byte
 }
 
+// RevertibleHostEnv is implemented by sub envs able to discard their changes,
+// the binding reverts a sub env created by CreateSubEnv as soon as its execution fails.
+type RevertibleHostEnv interface {
+	Revert() error
+}
+
+func revertSubEnv(env HostEnv) {
+	if revertible, ok := env.(RevertibleHostEnv); ok {
+		if err := revertible.Revert(); err != nil {
+			panic(err)
+		}
+	}
+}
+
 type GasMeter struct {
 	gasLimit    uint64
 	gasConsumed uint64
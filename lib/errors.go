@@ -1,10 +1,147 @@
 package lib
 
-type OutOfGas struct {
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrOutOfGas         = errors.New("out of gas")
+	ErrContractTrap     = errors.New("contract trap")
+	ErrHostPanic        = errors.New("host panic")
+	ErrCodeEmpty        = errors.New("code is empty")
+	ErrAlreadyDeployed  = errors.New("contract is already deployed")
+	ErrSubCallRejected  = errors.New("sub call rejected by host")
+	ErrDecodeResult     = errors.New("cannot decode action result")
+	ErrEmptyDescription = errors.New("error without description")
+	// ErrHostFunctionDisabled is returned if a contract calls a host function not enabled by the fork config yet
+	ErrHostFunctionDisabled = errors.New("host function is not enabled")
+	// ErrReadOnly is returned if a contract modifies state in the read-only mode
+	ErrReadOnly = errors.New("state modification in read-only mode")
+	// ErrCanceled is returned if the context of the execution is done, the error also matches the context error
+	ErrCanceled = errors.New("execution canceled")
+)
 
+type OutOfGas struct {
 }
 
 func (o OutOfGas) Error() string {
-	panic("out of gas")
+	return ErrOutOfGas.Error()
+}
+
+func (o OutOfGas) Is(target error) bool {
+	return target == ErrOutOfGas
+}
+
+// canceledError is the kind of an execution aborted because its context is done.
+type canceledError struct {
+	err error
+}
+
+func (c canceledError) Error() string {
+	return ErrCanceled.Error() + ": " + c.err.Error()
+}
+
+func (c canceledError) Is(target error) bool {
+	return target == ErrCanceled
+}
+
+func (c canceledError) Unwrap() error {
+	return c.err
+}
+
+// ExecutionError describes a failed execute or deploy action.
+type ExecutionError struct {
+	// Kind is one of the Err* values, it is matched by errors.Is
+	Kind error
+	// Message is the error reported for the root action
+	Message string
+	GasUsed uint64
+	// Path holds indexes of sub action results leading from the root to the node where the failure originated
+	Path     []int
+	Contract Address
+	Method   string
 }
 
+func (e *ExecutionError) Error() string {
+	if e.Message == "" {
+		return e.Kind.Error()
+	}
+	return e.Message
+}
+
+func (e *ExecutionError) Unwrap() error {
+	return e.Kind
+}
+
+func errorKind(err error) error {
+	var executionErr *ExecutionError
+	if errors.As(err, &executionErr) {
+		return executionErr.Kind
+	}
+	return err
+}
+
+func classifyErrorMessage(msg string) error {
+	switch {
+	case msg == ErrCodeEmpty.Error():
+		return ErrCodeEmpty
+	case msg == ErrAlreadyDeployed.Error():
+		return ErrAlreadyDeployed
+	case strings.Contains(strings.ToLower(msg), ErrOutOfGas.Error()):
+		return ErrOutOfGas
+	default:
+		return ErrContractTrap
+	}
+}
+
+func newExecutionError(api *GoAPI, result *ExecutionResult) *ExecutionError {
+	path := result.FailedPath()
+	node := result.node(path)
+	err := &ExecutionError{
+		Message:  result.Error,
+		GasUsed:  result.GasUsed,
+		Path:     path,
+		Contract: node.Contract,
+	}
+	if node.Action != nil {
+		err.Method = node.Action.Method
+	}
+	switch {
+	case len(path) > 0:
+		err.Kind = subCallKind(api, result, path[0], node)
+	case api.hostErr != nil:
+		err.Kind = api.hostErr
+	default:
+		err.Kind = classifyErrorMessage(node.Error)
+	}
+	return err
+}
+
+// subCallKind returns the kind of the failed sub action result with the given index, a nested execution error
+// already carries the kind of its own failing node. api.subCallErrs has an entry for every call and deploy callback,
+// so sub action results of transfers and reads are skipped to find the entry of the node.
+func subCallKind(api *GoAPI, result *ExecutionResult, idx int, node *ExecutionResult) error {
+	calls := 0
+	for _, sub := range result.SubResults[:idx] {
+		if isSubCallResult(sub) {
+			calls++
+		}
+	}
+	if isSubCallResult(result.SubResults[idx]) && calls < len(api.subCallErrs) && api.subCallErrs[calls] != nil {
+		return errorKind(api.subCallErrs[calls])
+	}
+	return classifyErrorMessage(node.Error)
+}
+
+func isSubCallResult(result *ExecutionResult) bool {
+	return result.Action != nil && (result.Action.Type == ActionFunctionCall || result.Action.Type == ActionDeployContract)
+}
+
+func newDecodeError(gasUsed uint64, err error) *ExecutionError {
+	return &ExecutionError{
+		Kind:    ErrDecodeResult,
+		Message: ErrDecodeResult.Error() + ": " + err.Error(),
+		GasUsed: gasUsed,
+	}
+}
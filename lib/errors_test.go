@@ -0,0 +1,42 @@
+package lib
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func subResult(actionType ActionType, success bool, msg string) *ExecutionResult {
+	return &ExecutionResult{Action: &Action{Type: actionType}, Success: success, Error: msg}
+}
+
+// TestExecutionErrorSubCallKind drives newExecutionError with sub action results of transfers and reads,
+// which have no entries among errors of call and deploy callbacks.
+func TestExecutionErrorSubCallKind(t *testing.T) {
+	api := &GoAPI{subCallErrs: []error{nil, ErrReadOnly}}
+	result := &ExecutionResult{Error: "sub call failed", SubResults: []*ExecutionResult{
+		subResult(ActionFunctionCall, true, ""),
+		subResult(ActionTransfer, true, ""),
+		subResult(ActionReadContractData, true, ""),
+		subResult(ActionDeployContract, false, ErrReadOnly.Error()),
+	}}
+	err := newExecutionError(api, result)
+	require.Equal(t, []int{3}, err.Path)
+	require.Equal(t, ErrReadOnly, err.Kind)
+
+	// a failed transfer does not take the kind of the preceding call
+	api = &GoAPI{subCallErrs: []error{ErrCodeEmpty}}
+	result = &ExecutionResult{Error: "transfer failed", SubResults: []*ExecutionResult{
+		subResult(ActionFunctionCall, true, ""),
+		subResult(ActionTransfer, false, "insufficient funds"),
+	}}
+	require.Equal(t, ErrContractTrap, newExecutionError(api, result).Kind)
+
+	// a nested execution error carries the kind of its failing node
+	nested := &ExecutionError{Kind: OutOfGas{}, Path: []int{0}}
+	api = &GoAPI{subCallErrs: []error{nested}}
+	result = &ExecutionResult{Error: "sub call failed", SubResults: []*ExecutionResult{
+		subResult(ActionReadIdentity, true, ""),
+		subResult(ActionFunctionCall, false, "out of gas"),
+	}}
+	require.ErrorIs(t, newExecutionError(api, result), ErrOutOfGas)
+}
@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIFactory returns a GoAPI over a disposable snapshot of the host state, it is called for every trial execution.
+type APIFactory func() (*GoAPI, error)
+
+type GasEstimate struct {
+	// GasLimit is the minimal gas limit the execution succeeds with
+	GasLimit uint64
+	// GasUsed is the gas used by the execution with GasLimit
+	GasUsed uint64
+	Result  *ExecutionResult
+	// Trials is the number of executions made by the estimation
+	Trials int
+}
+
+type estimateTrial func(api *GoAPI, gasLimit uint64) (*ExecutionResult, error)
+
+// EstimateGas finds the minimal gas limit not exceeding maxGas the call succeeds with.
+// An execution failed with maxGas is reported as is, so callers can tell out of gas from logic failures by errors.Is.
+// The minimal limit may be above the gas used since contracts pass gas limits to sub calls,
+// so the search continues above the gas used while trials run out of gas, any other failure is returned.
+func EstimateGas(factory APIFactory, code []byte, method string, args [][]byte, contractAddr Address, maxGas uint64, is_debug bool) (*GasEstimate, error) {
+	return estimateGas(factory, maxGas, func(api *GoAPI, gasLimit uint64) (*ExecutionResult, error) {
+		return ExecuteResult(api, code, method, args, contractAddr, gasLimit, is_debug)
+	})
+}
+
+// EstimateDeployGas is EstimateGas for a deploy.
+func EstimateDeployGas(factory APIFactory, code []byte, args [][]byte, contractAddr Address, maxGas uint64, is_debug bool) (*GasEstimate, error) {
+	return estimateGas(factory, maxGas, func(api *GoAPI, gasLimit uint64) (*ExecutionResult, error) {
+		return DeployResult(api, code, args, contractAddr, gasLimit, is_debug)
+	})
+}
+
+func estimateGas(factory APIFactory, maxGas uint64, trial estimateTrial) (*GasEstimate, error) {
+	estimate := &GasEstimate{}
+	run := func(gasLimit uint64) (*ExecutionResult, error) {
+		api, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("cannot create api: %w", err)
+		}
+		estimate.Trials++
+		return trial(api, gasLimit)
+	}
+	result, err := run(maxGas)
+	if err != nil {
+		return nil, err
+	}
+	estimate.GasLimit = maxGas
+	estimate.GasUsed = result.GasUsed
+	estimate.Result = result
+
+	succeed := func(gasLimit uint64) (bool, error) {
+		result, err := run(gasLimit)
+		if err != nil {
+			if errors.Is(err, ErrOutOfGas) {
+				return false, nil
+			}
+			return false, err
+		}
+		estimate.GasLimit = gasLimit
+		estimate.GasUsed = result.GasUsed
+		estimate.Result = result
+		return true, nil
+	}
+
+	// an execution without sub calls succeeds with the gas it used
+	lo, hi := result.GasUsed, maxGas
+	if lo == 0 || lo >= hi {
+		return estimate, nil
+	}
+	ok, err := succeed(lo)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return estimate, nil
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		ok, err := succeed(mid)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return estimate, nil
+}
@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// thresholdTrial fakes an execution using gasUsed, which runs out of gas with limits below threshold
+// since it passes a part of its limit to a sub call. Limits from failFrom up to maxGas fail with a logic error.
+func thresholdTrial(gasUsed, threshold, failFrom, maxGas uint64, limits *[]uint64) estimateTrial {
+	return func(api *GoAPI, gasLimit uint64) (*ExecutionResult, error) {
+		*limits = append(*limits, gasLimit)
+		if gasLimit < threshold {
+			return &ExecutionResult{GasUsed: gasLimit}, &ExecutionError{Kind: OutOfGas{}}
+		}
+		if gasLimit >= failFrom && gasLimit < maxGas {
+			return &ExecutionResult{GasUsed: gasUsed}, &ExecutionError{Kind: ErrContractTrap}
+		}
+		return &ExecutionResult{Success: true, GasUsed: gasUsed}, nil
+	}
+}
+
+func testAPIFactory() (*GoAPI, error) {
+	return NewGoAPI(newTestHost(), &GasMeter{}), nil
+}
+
+func TestEstimateGasThreshold(t *testing.T) {
+	var limits []uint64
+	estimate, err := estimateGas(testAPIFactory, 10000, thresholdTrial(1000, 4321, 10000, 10000, &limits))
+	require.NoError(t, err)
+	require.Equal(t, uint64(4321), estimate.GasLimit)
+	require.Equal(t, uint64(1000), estimate.GasUsed)
+	require.True(t, estimate.Result.Success)
+	require.Equal(t, len(limits), estimate.Trials)
+	require.Equal(t, []uint64{10000, 1000}, limits[:2])
+
+	// an execution succeeding with the gas it used needs two trials
+	limits = nil
+	estimate, err = estimateGas(testAPIFactory, 10000, thresholdTrial(1000, 1000, 10000, 10000, &limits))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), estimate.GasLimit)
+	require.Equal(t, 2, estimate.Trials)
+}
+
+func TestEstimateGasLogicError(t *testing.T) {
+	var limits []uint64
+	_, err := estimateGas(testAPIFactory, 10000, thresholdTrial(1000, 4321, 5000, 10000, &limits))
+	require.ErrorIs(t, err, ErrContractTrap)
+	require.False(t, errors.Is(err, ErrOutOfGas))
+	require.GreaterOrEqual(t, limits[len(limits)-1], uint64(5000))
+}
+
+func TestEstimateGasMaxGasInsufficient(t *testing.T) {
+	var limits []uint64
+	_, err := estimateGas(testAPIFactory, 4000, thresholdTrial(1000, 4321, 10000, 10000, &limits))
+	require.ErrorIs(t, err, ErrOutOfGas)
+	require.Equal(t, []uint64{4000}, limits)
+
+	_, err = estimateGas(func() (*GoAPI, error) {
+		return nil, errors.New("no state")
+	}, 4000, thresholdTrial(1000, 4321, 10000, 10000, &limits))
+	require.EqualError(t, err, "cannot create api: no state")
+}
@@ -0,0 +1,105 @@
+package lib
+
+type ContractEvent struct {
+	Contract Address
+	Name     string
+	Args     [][]byte
+	// Depth is the call depth of the emitting contract, the called contract has depth 0
+	Depth int
+	// Index is the position of the event among events of the execution
+	Index int
+}
+
+// EventSink receives events as contracts emit them. Revert is called when the sub call which emitted
+// events fails or the whole execution fails, the sink discards events received after the first index ones then.
+// The index counts events the sink kept in all executions of the api, while ContractEvent.Index restarts
+// in every execution, so a failed execution never discards events of the preceding ones.
+type EventSink interface {
+	Emit(event *ContractEvent)
+	Revert(index int)
+}
+
+// EventBuffer is an EventSink keeping events which are not reverted.
+type EventBuffer struct {
+	events []*ContractEvent
+}
+
+func NewEventBuffer() *EventBuffer {
+	return &EventBuffer{}
+}
+
+func (b *EventBuffer) Emit(event *ContractEvent) {
+	b.events = append(b.events, event)
+}
+
+func (b *EventBuffer) Revert(index int) {
+	if index < len(b.events) {
+		b.events = b.events[:index]
+	}
+}
+
+func (b *EventBuffer) Events() []*ContractEvent {
+	return b.events
+}
+
+// EventMessage is either an emitted event or a revert of events starting from RevertIndex.
+type EventMessage struct {
+	Event       *ContractEvent
+	RevertIndex int
+}
+
+// EventChannel is an EventSink streaming events of a transaction to a channel.
+// Sending blocks the execution, so the channel should be read concurrently or be buffered enough.
+type EventChannel struct {
+	ch chan EventMessage
+}
+
+func NewEventChannel(size int) *EventChannel {
+	return &EventChannel{ch: make(chan EventMessage, size)}
+}
+
+func (c *EventChannel) Emit(event *ContractEvent) {
+	c.ch <- EventMessage{Event: event}
+}
+
+func (c *EventChannel) Revert(index int) {
+	c.ch <- EventMessage{RevertIndex: index}
+}
+
+func (c *EventChannel) Messages() <-chan EventMessage {
+	return c.ch
+}
+
+// Close should be called when the transaction is executed.
+func (c *EventChannel) Close() {
+	close(c.ch)
+}
+
+// eventLog numbers events of an execution including sub calls, base is the number of events
+// the sink kept from the preceding executions.
+type eventLog struct {
+	sink  EventSink
+	base  int
+	count int
+}
+
+// reset starts numbering events of the next execution.
+func (l *eventLog) reset() {
+	l.base += l.count
+	l.count = 0
+}
+
+func (l *eventLog) emit(event *ContractEvent) {
+	event.Index = l.count
+	l.count++
+	l.sink.Emit(event)
+}
+
+// revert discards events of the current execution starting from the index.
+func (l *eventLog) revert(index int) {
+	if index >= l.count {
+		return
+	}
+	l.count = index
+	l.sink.Revert(l.base + index)
+}
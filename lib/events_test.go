@@ -0,0 +1,46 @@
+package lib
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// TestEventSinkSharedByExecutions runs executions as Execute does with a buffer shared by them,
+// a failed execution discards its own events only.
+func TestEventSinkSharedByExecutions(t *testing.T) {
+	buffer := NewEventBuffer()
+	api := NewGoAPI(newTestHost(), &GasMeter{})
+	api.SetEventSink(buffer)
+	api.applyForks()
+	state := buildAPI(api).state
+	emit := func(name string) {
+		var gasUsed cu64
+		require.Zero(t, cevent(state, constructU8SliceView([]byte(name)), constructU8SliceView(PackArguments(nil)), &gasUsed))
+	}
+
+	api.resetEvents()
+	emit("a")
+	emit("b")
+
+	// a failed execution
+	api.resetEvents()
+	emit("c")
+	api.revertEvents(0)
+
+	// an execution with a failed sub call
+	api.resetEvents()
+	emit("d")
+	index := api.eventIndex()
+	emit("e")
+	api.revertEvents(index)
+	emit("f")
+
+	var names []string
+	var indexes []int
+	for _, event := range buffer.Events() {
+		names = append(names, event.Name)
+		indexes = append(indexes, event.Index)
+	}
+	require.Equal(t, []string{"a", "b", "d", "f"}, names)
+	require.Equal(t, []int{0, 1, 0, 1}, indexes)
+}
@@ -0,0 +1,116 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Fork changes the gas schedule and enables host functions starting from the block height and the epoch,
+// a zero height or epoch is not checked.
+type Fork struct {
+	Name   string `json:"name"`
+	Height uint64 `json:"height,omitempty"`
+	Epoch  uint16 `json:"epoch,omitempty"`
+	// Schedule replaces the gas schedule of previous forks if set
+	Schedule *GasSchedule `json:"schedule,omitempty"`
+	// Enable lists host functions available since the fork, host functions not listed by any fork are always available
+	Enable []HostFunction `json:"enable,omitempty"`
+}
+
+func (f *Fork) IsActive(height uint64, epoch uint16) bool {
+	return height >= f.Height && epoch >= f.Epoch
+}
+
+// ForkConfig describes protocol upgrades, forks are ordered by activation.
+type ForkConfig struct {
+	// Schedule is used before the first fork with a schedule, the binding charges no gas while no schedule is set
+	Schedule *GasSchedule `json:"schedule,omitempty"`
+	Forks    []Fork       `json:"forks"`
+}
+
+// Rules are the gas schedule and the host functions availability at a block.
+type Rules struct {
+	Schedule *GasSchedule
+	Disabled map[HostFunction]struct{}
+}
+
+func (r *Rules) IsEnabled(fn HostFunction) bool {
+	_, ok := r.Disabled[fn]
+	return !ok
+}
+
+func ParseForkConfig(data []byte) (*ForkConfig, error) {
+	res := &ForkConfig{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *ForkConfig) Validate() error {
+	if c.Schedule != nil {
+		if err := c.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+	known := make(map[HostFunction]struct{}, len(HostFunctions))
+	for _, fn := range HostFunctions {
+		known[fn] = struct{}{}
+	}
+	enabled := map[HostFunction]string{}
+	// forks activated by height and by epoch are ordered independently of each other
+	var lastHeight uint64
+	var lastEpoch uint16
+	for _, fork := range c.Forks {
+		if fork.Height > 0 {
+			if fork.Height < lastHeight {
+				return fmt.Errorf("fork %v: forks are not ordered by activation height", fork.Name)
+			}
+			lastHeight = fork.Height
+		}
+		if fork.Epoch > 0 {
+			if fork.Epoch < lastEpoch {
+				return fmt.Errorf("fork %v: forks are not ordered by activation epoch", fork.Name)
+			}
+			lastEpoch = fork.Epoch
+		}
+		if fork.Schedule != nil {
+			if err := fork.Schedule.Validate(); err != nil {
+				return fmt.Errorf("fork %v: %w", fork.Name, err)
+			}
+		}
+		for _, fn := range fork.Enable {
+			if _, ok := known[fn]; !ok {
+				return fmt.Errorf("fork %v: unknown host function %q", fork.Name, fn)
+			}
+			if name, ok := enabled[fn]; ok {
+				return fmt.Errorf("fork %v: host function %v is already enabled by fork %v", fork.Name, fn, name)
+			}
+			enabled[fn] = fork.Name
+		}
+	}
+	return nil
+}
+
+// Rules returns the rules of the block with the height and the epoch.
+func (c *ForkConfig) Rules(height uint64, epoch uint16) *Rules {
+	res := &Rules{
+		Schedule: c.Schedule,
+		Disabled: map[HostFunction]struct{}{},
+	}
+	for _, fork := range c.Forks {
+		if fork.IsActive(height, epoch) {
+			if fork.Schedule != nil {
+				res.Schedule = fork.Schedule
+			}
+			continue
+		}
+		for _, fn := range fork.Enable {
+			res.Disabled[fn] = struct{}{}
+		}
+	}
+	return res
+}
@@ -0,0 +1,99 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type GasCost struct {
+	Base    uint64 `json:"base,omitempty"`
+	PerByte uint64 `json:"perByte,omitempty"`
+}
+
+func (c GasCost) Cost(size int) uint64 {
+	return c.Base + c.PerByte*uint64(size)
+}
+
+// GasSchedule defines gas the binding charges for host functions in addition to gas charged by HostEnv.
+// Per byte cost applies to variable-size data passed to the host function and returned from it.
+// Call and deploy costs cover passing arguments and code only, gas of the nested execution is added to them.
+type GasSchedule struct {
+	Version uint32                   `json:"version"`
+	Costs   map[HostFunction]GasCost `json:"costs"`
+}
+
+func DefaultGasSchedule() *GasSchedule {
+	const getter = 10
+	return &GasSchedule{
+		Version: 1,
+		Costs: map[HostFunction]GasCost{
+			HostSetStorage:         {Base: 100, PerByte: 10},
+			HostGetStorage:         {Base: 50, PerByte: 10},
+			HostRemoveStorage:      {Base: 50, PerByte: 10},
+			HostReadContractData:   {Base: 50, PerByte: 10},
+			HostBlockTimestamp:     {Base: getter},
+			HostBlockNumber:        {Base: getter},
+			HostMinFeePerGas:       {Base: getter},
+			HostBalance:            {Base: getter},
+			HostBlockSeed:          {Base: getter},
+			HostNetworkSize:        {Base: getter},
+			HostCaller:             {Base: getter},
+			HostOriginalCaller:     {Base: getter},
+			HostContract:           {Base: getter},
+			HostCodeHash:           {Base: getter},
+			HostEpoch:              {Base: getter},
+			HostPayAmount:          {Base: getter},
+			HostGlobalState:        {Base: 50, PerByte: 1},
+			HostIdentity:           {Base: 50, PerByte: 1},
+			HostBlockHeader:        {Base: 50, PerByte: 1},
+			HostOwnCode:            {Base: 50, PerByte: 1},
+			HostDeductBalance:      {Base: 100},
+			HostAddBalance:         {Base: 100},
+			HostBurn:               {Base: 100},
+			HostEvent:              {Base: 100, PerByte: 10},
+			HostKeccak256:          {Base: 30, PerByte: 6},
+			HostContractAddr:       {Base: 30, PerByte: 6},
+			HostContractAddrByHash: {Base: 30, PerByte: 6},
+			HostEcrecover:          {Base: 3000},
+			HostCall:               {Base: 500, PerByte: 10},
+			HostDeploy:             {Base: 1000, PerByte: 10},
+		},
+	}
+}
+
+func ParseGasSchedule(data []byte) (*GasSchedule, error) {
+	res := &GasSchedule{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *GasSchedule) Marshal() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Validate checks that costs are defined for known host functions only, a missing host function costs nothing.
+func (s *GasSchedule) Validate() error {
+	known := make(map[HostFunction]struct{}, len(HostFunctions))
+	for _, fn := range HostFunctions {
+		known[fn] = struct{}{}
+	}
+	for fn := range s.Costs {
+		if _, ok := known[fn]; !ok {
+			return fmt.Errorf("unknown host function %q", fn)
+		}
+	}
+	return nil
+}
+
+// Cost returns the cost of the host function, nil schedule charges nothing.
+func (s *GasSchedule) Cost(fn HostFunction) GasCost {
+	if s == nil {
+		return GasCost{}
+	}
+	return s.Costs[fn]
+}
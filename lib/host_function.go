@@ -0,0 +1,61 @@
+package lib
+
+// HostFunction identifies a host callback exposed to contracts, names match the GoApi vtable.
+type HostFunction string
+
+const (
+	HostSetStorage         HostFunction = "set_storage"
+	HostGetStorage         HostFunction = "get_storage"
+	HostRemoveStorage      HostFunction = "remove_storage"
+	HostBlockTimestamp     HostFunction = "block_timestamp"
+	HostBlockNumber        HostFunction = "block_number"
+	HostMinFeePerGas       HostFunction = "min_fee_per_gas"
+	HostBalance            HostFunction = "balance"
+	HostBlockSeed          HostFunction = "block_seed"
+	HostNetworkSize        HostFunction = "network_size"
+	HostIdentity           HostFunction = "identity"
+	HostCaller             HostFunction = "caller"
+	HostOriginalCaller     HostFunction = "original_caller"
+	HostDeductBalance      HostFunction = "deduct_balance"
+	HostAddBalance         HostFunction = "add_balance"
+	HostContract           HostFunction = "contract"
+	HostCall               HostFunction = "call"
+	HostDeploy             HostFunction = "deploy"
+	HostContractAddr       HostFunction = "contract_addr"
+	HostContractAddrByHash HostFunction = "contract_addr_by_hash"
+	HostOwnCode            HostFunction = "own_code"
+	HostCodeHash           HostFunction = "code_hash"
+	HostEpoch              HostFunction = "epoch"
+	HostReadContractData   HostFunction = "read_contract_data"
+	HostPayAmount          HostFunction = "pay_amount"
+	HostEvent              HostFunction = "event"
+	HostBlockHeader        HostFunction = "block_header"
+	HostKeccak256          HostFunction = "keccak256"
+	HostGlobalState        HostFunction = "global_state"
+	HostBurn               HostFunction = "burn"
+	HostEcrecover          HostFunction = "ecrecover"
+)
+
+// HostFunctions lists all host functions in the vtable order.
+var HostFunctions = []HostFunction{
+	HostSetStorage, HostGetStorage, HostRemoveStorage, HostBlockTimestamp, HostBlockNumber, HostMinFeePerGas,
+	HostBalance, HostBlockSeed, HostNetworkSize, HostIdentity, HostCaller, HostOriginalCaller, HostDeductBalance,
+	HostAddBalance, HostContract, HostCall, HostDeploy, HostContractAddr, HostContractAddrByHash, HostOwnCode,
+	HostCodeHash, HostEpoch, HostReadContractData, HostPayAmount, HostEvent, HostBlockHeader, HostKeccak256,
+	HostGlobalState, HostBurn, HostEcrecover,
+}
+
+// IsSubCall reports whether gas of the host function includes gas of a nested execution.
+func (f HostFunction) IsSubCall() bool {
+	return f == HostCall || f == HostDeploy
+}
+
+// IsMutating reports whether the host function always modifies state, call modifies state only if it carries value.
+func (f HostFunction) IsMutating() bool {
+	switch f {
+	case HostSetStorage, HostRemoveStorage, HostAddBalance, HostDeductBalance, HostBurn, HostDeploy, HostEvent:
+		return true
+	default:
+		return false
+	}
+}
@@ -4,7 +4,7 @@ package lib
 // #include "bindings.h"
 import "C"
 import (
-	"errors"
+	"context"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	models "github.com/idena-network/idena-wasm-binding/lib/protobuf"
@@ -47,42 +47,70 @@ func copyAndDestroyUnmanagedVector(v C.UnmanagedVector) []byte {
 func errorWithMessage(err int, b C.UnmanagedVector) error {
 	// this checks for out of gas as a special case
 	if err == 2 {
-		return errors.New("out of gas")
+		return ErrOutOfGas
 	}
 	msg := copyAndDestroyUnmanagedVector(b)
 	if msg == nil {
-		return errors.New("error without description")
+		return ErrEmptyDescription
 	}
 	return fmt.Errorf("%s", string(msg))
 }
 
 func Execute(api *GoAPI, code []byte, method string, args [][]byte, contractAddr Address, gasLimit uint64, is_debug bool) (uint64, []byte, error) {
-
+	api.applyForks()
+	api.resetEvents()
 	gas, actionResult, err := execute(api, code, []byte(method), PackArguments(args), []byte{}, contractAddr, gasLimit, is_debug)
+	if err != nil {
+		api.revertEvents(0)
+	}
 	return gas, actionResult, err
 }
 
 func Deploy(api *GoAPI, code []byte, args [][]byte, contractAddr Address, gasLimit uint64, is_debug bool) (uint64, []byte, error) {
+	api.applyForks()
+	api.resetEvents()
 	gas, actionResult, err := deploy(api, code, PackArguments(args), contractAddr, gasLimit, is_debug)
+	if err != nil {
+		api.revertEvents(0)
+	}
 	return gas, actionResult, err
 }
 
+// ExecuteContext is Execute aborted at the next host callback once the context is done,
+// wasm code running between callbacks is limited by gas only. The error of an aborted execution
+// matches ErrCanceled and the context error, the returned gas is the gas used before the abort.
+func ExecuteContext(ctx context.Context, api *GoAPI, code []byte, method string, args [][]byte, contractAddr Address, gasLimit uint64, is_debug bool) (uint64, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, nil, &ExecutionError{Kind: canceledError{err}, Contract: contractAddr, Method: method}
+	}
+	api.ctx = ctx
+	defer func() {
+		api.ctx = nil
+	}()
+	return Execute(api, code, method, args, contractAddr, gasLimit, is_debug)
+}
+
+// DeployContext is Deploy aborted at the next host callback once the context is done, see ExecuteContext.
+func DeployContext(ctx context.Context, api *GoAPI, code []byte, args [][]byte, contractAddr Address, gasLimit uint64, is_debug bool) (uint64, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, nil, &ExecutionError{Kind: canceledError{err}, Contract: contractAddr, Method: "deploy"}
+	}
+	api.ctx = ctx
+	defer func() {
+		api.ctx = nil
+	}()
+	return Deploy(api, code, args, contractAddr, gasLimit, is_debug)
+}
+
 func execute(api *GoAPI, code []byte, method []byte, args []byte, invocationContext []byte, contractAddr Address, gasLimit uint64, is_debug bool) (uint64, []byte, error) {
 
 	actionResult := newUnmanagedVector(nil)
 
 	var gasUsed cu64
+	api.contract = contractAddr
+	api.resetErrors()
 	C.execute(buildAPI(api), makeView(code), makeView(method), makeView(args), makeView(invocationContext), makeView(contractAddr[:]), cu64(gasLimit), &gasUsed, &actionResult, cbool(is_debug))
-	actionResultBytes := copyAndDestroyUnmanagedVector(actionResult)
-	protoModel := models.ActionResult{}
-	if err := proto.Unmarshal(actionResultBytes, &protoModel); err != nil {
-		return uint64(gasUsed), actionResultBytes, err
-	}
-	truncateActionResultData(&protoModel, 1)
-	if protoModel.Success {
-		return protoModel.GasUsed, actionResultBytes, nil
-	}
-	return protoModel.GasUsed, actionResultBytes, errors.New(protoModel.Error)
+	return handleActionResult(api, uint64(gasUsed), copyAndDestroyUnmanagedVector(actionResult))
 }
 
 const MaxArgsLength = 100
@@ -105,17 +133,22 @@ func deploy(api *GoAPI, code []byte, args []byte, contractAddr Address, gasLimit
 	actionResult := newUnmanagedVector(nil)
 
 	var gasUsed cu64
+	api.contract = contractAddr
+	api.resetErrors()
 	C.deploy(buildAPI(api), makeView(code), makeView(args), makeView(contractAddr[:]), cu64(gasLimit), &gasUsed, &actionResult, cbool(is_debug))
-	actionResultBytes := copyAndDestroyUnmanagedVector(actionResult)
+	return handleActionResult(api, uint64(gasUsed), copyAndDestroyUnmanagedVector(actionResult))
+}
+
+func handleActionResult(api *GoAPI, gasUsed uint64, actionResultBytes []byte) (uint64, []byte, error) {
 	protoModel := models.ActionResult{}
 	if err := proto.Unmarshal(actionResultBytes, &protoModel); err != nil {
-		return uint64(gasUsed), actionResultBytes, err
+		return gasUsed, actionResultBytes, newDecodeError(gasUsed, err)
 	}
 	truncateActionResultData(&protoModel, 1)
 	if protoModel.Success {
 		return protoModel.GasUsed, actionResultBytes, nil
 	}
-	return protoModel.GasUsed, actionResultBytes, errors.New(protoModel.Error)
+	return protoModel.GasUsed, actionResultBytes, newExecutionError(api, NewExecutionResult(&protoModel))
 }
 
 func PackArguments(args [][]byte) []byte {
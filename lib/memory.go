@@ -18,6 +18,17 @@ func constructUnmanagedVector(is_none cbool, ptr cu8_ptr, len cusize, cap cusize
 	}
 }
 
+// Creates a C.U8SliceView, which cannot be done in test files directly
+func constructU8SliceView(data []byte) C.U8SliceView {
+	if data == nil {
+		return C.U8SliceView{is_none: true, ptr: cu8_ptr(nil), len: C.uintptr_t(0)}
+	}
+	if len(data) == 0 {
+		return C.U8SliceView{is_none: false, ptr: cu8_ptr(nil), len: C.uintptr_t(0)}
+	}
+	return C.U8SliceView{is_none: false, ptr: cu8_ptr(unsafe.Pointer(&data[0])), len: C.uintptr_t(len(data))}
+}
+
 func newUnmanagedVector(data []byte) C.UnmanagedVector {
 	if data == nil {
 		return C.new_unmanaged_vector(cbool(true), cu8_ptr(nil), cusize(0))
@@ -0,0 +1,189 @@
+package lib
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const DefaultStorageKeyPrefixLength = 1
+
+type GasStat struct {
+	Calls uint64
+	Gas   uint64
+}
+
+func (s *GasStat) add(gas uint64) {
+	s.Calls++
+	s.Gas += gas
+}
+
+// ContractGas is gas spent by a contract itself, gas of its sub calls is attributed to the called contracts.
+type ContractGas struct {
+	// Executions is the number of action results of the contract
+	Executions uint64
+	// HostGas is gas charged by host functions called by the contract, call and deploy add their own charge
+	// without gas of the nested execution
+	HostGas uint64
+	// TotalGas is ActionResult.GasUsed of the contract executions minus gas used by their sub calls
+	TotalGas uint64
+}
+
+// WasmGas is gas charged by the VM for wasm instructions and memory.
+func (c *ContractGas) WasmGas() uint64 {
+	if c.TotalGas < c.HostGas {
+		return 0
+	}
+	return c.TotalGas - c.HostGas
+}
+
+// GasProfile aggregates gas reported by host callbacks of an execution including nested calls.
+// Gas of call and deploy host functions is the gas used by the whole nested execution.
+type GasProfile struct {
+	StorageKeyPrefixLength int
+	TotalGas               uint64
+	HostFunctions          map[HostFunction]*GasStat
+	Contracts              map[Address]*ContractGas
+	// StoragePrefixes is keyed by hex of storage key prefixes, it covers completed storage operations
+	// and reads of other contracts data
+	StoragePrefixes map[string]*GasStat
+
+	// subCallCharges is gas charged by call and deploy host functions themselves
+	subCallCharges uint64
+}
+
+func NewGasProfile(storageKeyPrefixLength int) *GasProfile {
+	return &GasProfile{
+		StorageKeyPrefixLength: storageKeyPrefixLength,
+		HostFunctions:          map[HostFunction]*GasStat{},
+		Contracts:              map[Address]*ContractGas{},
+		StoragePrefixes:        map[string]*GasStat{},
+	}
+}
+
+func (p *GasProfile) contract(addr Address) *ContractGas {
+	c, ok := p.Contracts[addr]
+	if !ok {
+		c = &ContractGas{}
+		p.Contracts[addr] = c
+	}
+	return c
+}
+
+func (p *GasProfile) addHostFunction(contract Address, fn HostFunction, gas uint64) {
+	stat, ok := p.HostFunctions[fn]
+	if !ok {
+		stat = &GasStat{}
+		p.HostFunctions[fn] = stat
+	}
+	stat.add(gas)
+	if !fn.IsSubCall() {
+		p.contract(contract).HostGas += gas
+	}
+}
+
+// addSubCallCharge adds the charge of a call or deploy host function, which its gas in HostFunctions
+// includes along with gas of the nested execution.
+func (p *GasProfile) addSubCallCharge(contract Address, gas uint64) {
+	p.contract(contract).HostGas += gas
+	p.subCallCharges += gas
+}
+
+func (p *GasProfile) addStorageKey(key []byte, gas uint64) {
+	if len(key) > p.StorageKeyPrefixLength {
+		key = key[:p.StorageKeyPrefixLength]
+	}
+	prefix := hex.EncodeToString(key)
+	stat, ok := p.StoragePrefixes[prefix]
+	if !ok {
+		stat = &GasStat{}
+		p.StoragePrefixes[prefix] = stat
+	}
+	stat.add(gas)
+}
+
+// AddExecutionResult adds wasm-side gas of the result tree. ExecuteResult and DeployResult call it
+// for the profile set to GoAPI, it should be called explicitly only after Execute and Deploy.
+func (p *GasProfile) AddExecutionResult(result *ExecutionResult) {
+	p.TotalGas += result.GasUsed
+	result.Walk(func(node *ExecutionResult, depth int) bool {
+		var subCallsGas uint64
+		for _, sub := range node.SubResults {
+			subCallsGas += sub.GasUsed
+		}
+		c := p.contract(node.Contract)
+		c.Executions++
+		if node.GasUsed > subCallsGas {
+			c.TotalGas += node.GasUsed - subCallsGas
+		}
+		return true
+	})
+}
+
+// HostGas returns gas charged by host functions excluding gas of nested executions.
+func (p *GasProfile) HostGas() uint64 {
+	res := p.subCallCharges
+	for fn, stat := range p.HostFunctions {
+		if !fn.IsSubCall() {
+			res += stat.Gas
+		}
+	}
+	return res
+}
+
+// String formats the profile as a plain text report sorted by gas.
+func (p *GasProfile) String() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "total gas: %v, host gas: %v\n", p.TotalGas, p.HostGas())
+
+	fns := make([]HostFunction, 0, len(p.HostFunctions))
+	for fn := range p.HostFunctions {
+		fns = append(fns, fn)
+	}
+	sort.Slice(fns, func(i, j int) bool {
+		a, b := p.HostFunctions[fns[i]], p.HostFunctions[fns[j]]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		return fns[i] < fns[j]
+	})
+	sb.WriteString("host functions:\n")
+	for _, fn := range fns {
+		fmt.Fprintf(sb, "  %-22v calls: %-6v gas: %v\n", fn, p.HostFunctions[fn].Calls, p.HostFunctions[fn].Gas)
+	}
+
+	contracts := make([]Address, 0, len(p.Contracts))
+	for addr := range p.Contracts {
+		contracts = append(contracts, addr)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		a, b := p.Contracts[contracts[i]], p.Contracts[contracts[j]]
+		if a.TotalGas != b.TotalGas {
+			return a.TotalGas > b.TotalGas
+		}
+		return hex.EncodeToString(contracts[i][:]) < hex.EncodeToString(contracts[j][:])
+	})
+	sb.WriteString("contracts:\n")
+	for _, addr := range contracts {
+		c := p.Contracts[addr]
+		fmt.Fprintf(sb, "  0x%x executions: %-4v total: %-10v wasm: %-10v host: %v\n", addr[:], c.Executions, c.TotalGas, c.WasmGas(), c.HostGas)
+	}
+
+	prefixes := make([]string, 0, len(p.StoragePrefixes))
+	for prefix := range p.StoragePrefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		a, b := p.StoragePrefixes[prefixes[i]], p.StoragePrefixes[prefixes[j]]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		return prefixes[i] < prefixes[j]
+	})
+	sb.WriteString("storage key prefixes:\n")
+	for _, prefix := range prefixes {
+		fmt.Fprintf(sb, "  0x%-20v calls: %-6v gas: %v\n", prefix, p.StoragePrefixes[prefix].Calls, p.StoragePrefixes[prefix].Gas)
+	}
+	return sb.String()
+}
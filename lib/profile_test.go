@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// TestGasProfileCallbacks profiles callbacks of a contract with a gas schedule, the charge of a call
+// is host gas of the caller while gas of the nested execution is not.
+func TestGasProfileCallbacks(t *testing.T) {
+	contract := Address{0x1}
+	schedule := DefaultGasSchedule()
+	profile := NewGasProfile(DefaultStorageKeyPrefixLength)
+	api := NewGoAPIWithGasSchedule(newTestHost(), &GasMeter{}, schedule)
+	api.SetGasProfile(profile)
+	api.applyForks()
+	api.contract = contract
+	state := buildAPI(api).state
+
+	var gasUsed cu64
+	require.Zero(t, cset_storage(state, constructU8SliceView([]byte("ab")), constructU8SliceView([]byte("v")), &gasUsed))
+	storageGas := schedule.Cost(HostSetStorage).Cost(len("ab") + len("v"))
+	require.Equal(t, cu64(storageGas), gasUsed)
+
+	// the test host rejects sub calls, so the call uses its charge only
+	actionResult := newUnmanagedVector(nil)
+	addr := Address{0x2}
+	require.NotZero(t, ccall(state, constructU8SliceView(addr[:]), constructU8SliceView([]byte("inc")), constructU8SliceView(nil),
+		constructU8SliceView(nil), constructU8SliceView(nil), 1000, &gasUsed, &actionResult))
+	callGas := schedule.Cost(HostCall).Cost(len("inc"))
+	require.Equal(t, cu64(callGas), gasUsed)
+
+	require.Equal(t, &GasStat{Calls: 1, Gas: storageGas}, profile.HostFunctions[HostSetStorage])
+	require.Equal(t, &GasStat{Calls: 1, Gas: callGas}, profile.HostFunctions[HostCall])
+	require.Equal(t, &GasStat{Calls: 1, Gas: storageGas}, profile.StoragePrefixes["61"])
+	require.Equal(t, storageGas+callGas, profile.Contracts[contract].HostGas)
+	require.Equal(t, storageGas+callGas, profile.HostGas())
+
+	profile.AddExecutionResult(&ExecutionResult{Contract: contract, GasUsed: 5000})
+	require.Equal(t, 5000-storageGas-callGas, profile.Contracts[contract].WasmGas())
+}
+
+func TestGasProfileSubCallGas(t *testing.T) {
+	caller, callee := Address{0x1}, Address{0x2}
+	profile := NewGasProfile(DefaultStorageKeyPrefixLength)
+	profile.addHostFunction(callee, HostSetStorage, 100)
+	profile.addSubCallCharge(caller, 50)
+	// gas of the call callback includes the nested execution
+	profile.addHostFunction(caller, HostCall, 350)
+	profile.AddExecutionResult(&ExecutionResult{
+		Contract:   caller,
+		GasUsed:    1000,
+		SubResults: []*ExecutionResult{{Contract: callee, GasUsed: 300}},
+	})
+
+	require.Equal(t, uint64(50), profile.Contracts[caller].HostGas)
+	require.Equal(t, uint64(650), profile.Contracts[caller].WasmGas())
+	require.Equal(t, uint64(100), profile.Contracts[callee].HostGas)
+	require.Equal(t, uint64(200), profile.Contracts[callee].WasmGas())
+	require.Equal(t, uint64(150), profile.HostGas())
+}
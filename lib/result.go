@@ -0,0 +1,170 @@
+package lib
+
+import (
+	"github.com/golang/protobuf/proto"
+	models "github.com/idena-network/idena-wasm-binding/lib/protobuf"
+	"math/big"
+)
+
+type ActionType uint32
+
+func (t ActionType) String() string {
+	switch t {
+	case ActionFunctionCall:
+		return "call"
+	case ActionTransfer:
+		return "transfer"
+	case ActionDeployContract:
+		return "deploy"
+	case ActionReadContractData:
+		return "read_contract_data"
+	case ActionReadIdentity:
+		return "read_identity"
+	default:
+		return "unknown"
+	}
+}
+
+type Action struct {
+	Type     ActionType
+	Amount   *big.Int
+	Method   string
+	Args     []byte
+	GasLimit uint64
+	Code     []byte
+	Nonce    []byte
+	Key      []byte
+}
+
+// Arguments returns the unpacked call arguments of the action.
+func (a *Action) Arguments() [][]byte {
+	return UnpackArguments(a.Args)
+}
+
+// ExecutionResult is a decoded models.ActionResult tree.
+type ExecutionResult struct {
+	Action       *Action
+	Contract     Address
+	Success      bool
+	Error        string
+	GasUsed      uint64
+	RemainingGas uint64
+	OutputData   []byte
+	SubResults   []*ExecutionResult
+
+	// Raw holds the marshaled action result as returned by the VM, it is set for the root node only
+	Raw []byte
+}
+
+func DecodeExecutionResult(data []byte) (*ExecutionResult, error) {
+	protoModel := models.ActionResult{}
+	if err := proto.Unmarshal(data, &protoModel); err != nil {
+		return nil, err
+	}
+	result := NewExecutionResult(&protoModel)
+	result.Raw = data
+	return result, nil
+}
+
+func NewExecutionResult(actionResult *models.ActionResult) *ExecutionResult {
+	result := &ExecutionResult{
+		Contract:     newAddress(actionResult.Contract),
+		Success:      actionResult.Success,
+		Error:        actionResult.Error,
+		GasUsed:      actionResult.GasUsed,
+		RemainingGas: actionResult.RemainingGas,
+		OutputData:   actionResult.OutputData,
+	}
+	if input := actionResult.InputAction; input != nil {
+		result.Action = &Action{
+			Type:     ActionType(input.ActionType),
+			Amount:   big.NewInt(0).SetBytes(input.Amount),
+			Method:   input.Method,
+			Args:     input.Args,
+			GasLimit: input.GasLimit,
+			Code:     input.Code,
+			Nonce:    input.Nonce,
+			Key:      input.Key,
+		}
+	}
+	for _, sub := range actionResult.SubActionResults {
+		result.SubResults = append(result.SubResults, NewExecutionResult(sub))
+	}
+	return result
+}
+
+// Walk visits the tree in depth-first pre-order. Children of a node are skipped if fn returns false for it.
+func (r *ExecutionResult) Walk(fn func(node *ExecutionResult, depth int) bool) {
+	r.walk(fn, 0)
+}
+
+func (r *ExecutionResult) walk(fn func(node *ExecutionResult, depth int) bool, depth int) {
+	if !fn(r, depth) {
+		return
+	}
+	for _, sub := range r.SubResults {
+		sub.walk(fn, depth+1)
+	}
+}
+
+// Flatten returns all nodes of the tree in depth-first pre-order.
+func (r *ExecutionResult) Flatten() []*ExecutionResult {
+	var nodes []*ExecutionResult
+	r.Walk(func(node *ExecutionResult, _ int) bool {
+		nodes = append(nodes, node)
+		return true
+	})
+	return nodes
+}
+
+// FailedNode returns the node where the failure originated or nil if the action succeeded.
+// A failure is attributed to a sub-call only if it was the last action made by the failed caller.
+func (r *ExecutionResult) FailedNode() *ExecutionResult {
+	if r.Success {
+		return nil
+	}
+	return r.node(r.FailedPath())
+}
+
+// FailedPath returns indexes of sub results leading to the FailedNode, it is empty if the failure originated in the root.
+func (r *ExecutionResult) FailedPath() []int {
+	path := []int{}
+	for node := r; !node.Success && len(node.SubResults) > 0; {
+		last := len(node.SubResults) - 1
+		if node.SubResults[last].Success {
+			break
+		}
+		path = append(path, last)
+		node = node.SubResults[last]
+	}
+	return path
+}
+
+func (r *ExecutionResult) node(path []int) *ExecutionResult {
+	node := r
+	for _, idx := range path {
+		node = node.SubResults[idx]
+	}
+	return node
+}
+
+func ExecuteResult(api *GoAPI, code []byte, method string, args [][]byte, contractAddr Address, gasLimit uint64, is_debug bool) (*ExecutionResult, error) {
+	gas, actionResult, err := Execute(api, code, method, args, contractAddr, gasLimit, is_debug)
+	return toExecutionResult(api, gas, actionResult, err)
+}
+
+func DeployResult(api *GoAPI, code []byte, args [][]byte, contractAddr Address, gasLimit uint64, is_debug bool) (*ExecutionResult, error) {
+	gas, actionResult, err := Deploy(api, code, args, contractAddr, gasLimit, is_debug)
+	return toExecutionResult(api, gas, actionResult, err)
+}
+
+func toExecutionResult(api *GoAPI, gas uint64, actionResult []byte, err error) (*ExecutionResult, error) {
+	result, decodeErr := DecodeExecutionResult(actionResult)
+	if decodeErr != nil {
+		return &ExecutionResult{GasUsed: gas, Error: decodeErr.Error(), Raw: actionResult}, newDecodeError(gas, decodeErr)
+	}
+	if api.profile != nil {
+		api.profile.AddExecutionResult(result)
+	}
+	return result, err
+}
@@ -0,0 +1,10 @@
+package lib
+
+// CallbackTracer observes host callbacks handled by the binding. Callbacks of a nested execution start and finish
+// between the start and the finish of the call or deploy callback running it.
+type CallbackTracer interface {
+	CallbackStarted(fn HostFunction, contract Address, depth int)
+	// CallbackFinished reports the gas returned to the VM by the callback, for call and deploy it includes
+	// gas of the nested execution. err is nil if the callback succeeded.
+	CallbackFinished(fn HostFunction, gasUsed uint64, err error)
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"github.com/idena-network/idena-wasm-binding/args"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+const tokenABI = `{
+  "name": "token",
+  "methods": [
+    {"name": "deploy", "inputs": [{"name": "supply", "type": "amount"}]},
+    {"name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "amount"}], "output": "bool"},
+    {"name": "balanceOf", "inputs": [{"name": "owner", "type": "address"}], "output": "amount", "view": true},
+    {"name": "memo", "inputs": [{"name": "text", "type": "string", "nullable": true}]}
+  ],
+  "events": [
+    {"name": "transfer", "args": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "amount", "type": "amount"}]}
+  ]
+}`
+
+func TestABIEncoding(t *testing.T) {
+	contractABI, err := abi.Parse([]byte(tokenABI))
+	require.NoError(t, err)
+
+	transfer, ok := contractABI.Method("transfer")
+	require.True(t, ok)
+	encoded, err := transfer.EncodeInputs("0x0100000000000000000000000000000000000002", 1000)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{args.Address(lib.Address{0x1, 19: 0x2}), {0x3, 0xe8}}, encoded)
+
+	_, err = transfer.EncodeInputs(lib.Address{}, -1)
+	require.Error(t, err)
+	_, err = transfer.EncodeInputs(lib.Address{})
+	require.Error(t, err)
+
+	memo, _ := contractABI.Method("memo")
+	encoded, err = memo.EncodeInputs(nil)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{nil}, encoded)
+
+	output, err := transfer.DecodeOutput([]byte{1})
+	require.NoError(t, err)
+	require.Equal(t, true, output)
+
+	event, ok := contractABI.Event("transfer")
+	require.True(t, ok)
+	values, err := event.DecodeArgs([][]byte{args.Address(lib.Address{0x1}), args.Address(lib.Address{0x2}), {0x10}})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{lib.Address{0x1}, lib.Address{0x2}, big.NewInt(16)}, values)
+
+	// missing values of non-nullable arguments fail, empty values are valid amounts, strings and bytes only
+	values, err = event.DecodeArgs([][]byte{args.Address(lib.Address{0x1}), args.Address(lib.Address{0x2}), {}})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{lib.Address{0x1}, lib.Address{0x2}, new(big.Int)}, values)
+	_, err = event.DecodeArgs([][]byte{args.Address(lib.Address{0x1}), nil, {0x10}})
+	require.ErrorIs(t, err, args.ErrNilArgument)
+	_, err = event.DecodeArgs([][]byte{args.Address(lib.Address{0x1}), {}, {0x10}})
+	require.ErrorIs(t, err, args.ErrInvalidLength)
+	_, err = event.DecodeArgs([][]byte{args.Address(lib.Address{0x1}), args.Address(lib.Address{0x2}), nil})
+	require.ErrorIs(t, err, args.ErrNilArgument)
+	_, err = abi.DecodeArguments([]abi.Argument{{Type: abi.TypeBytes}}, [][]byte{nil})
+	require.ErrorIs(t, err, args.ErrNilArgument)
+
+	values, err = abi.DecodeArguments(memo.Inputs, [][]byte{{}})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{""}, values)
+	values, err = abi.DecodeArguments(memo.Inputs, [][]byte{nil})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{nil}, values)
+
+	// outputs decode empty values the same way
+	_, err = transfer.DecodeOutput([]byte{})
+	require.ErrorIs(t, err, args.ErrInvalidLength)
+	balanceOf, _ := contractABI.Method("balanceOf")
+	output, err = balanceOf.DecodeOutput([]byte{})
+	require.NoError(t, err)
+	require.Equal(t, new(big.Int), output)
+}
+
+func TestABIValidation(t *testing.T) {
+	_, err := abi.Parse([]byte(`{"methods": [{"name": "a", "inputs": [{"type": "u128"}]}]}`))
+	require.Error(t, err)
+	_, err = abi.Parse([]byte(`{"methods": [{"name": "a"}, {"name": "a"}]}`))
+	require.Error(t, err)
+	_, err = abi.Parse([]byte(`{"methods": [{"name": "a", "output": "float"}]}`))
+	require.Error(t, err)
+}
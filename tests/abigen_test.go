@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"github.com/idena-network/idena-wasm-binding/abi/gen"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func typeCheck(t *testing.T, code []byte) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", code, parser.AllErrors)
+	require.NoError(t, err)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("client", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+}
+
+func TestGenerateClient(t *testing.T) {
+	contractABI, err := abi.Parse([]byte(tokenABI))
+	require.NoError(t, err)
+
+	code, err := gen.Generate(contractABI, gen.Options{Package: "token"})
+	require.NoError(t, err)
+
+	typeCheck(t, code)
+	require.Contains(t, string(code), "func (c *Token) Deploy(api *lib.GoAPI, supply *big.Int) (*lib.ExecutionResult, error)")
+	require.Contains(t, string(code), "func (c *Token) Transfer(api *lib.GoAPI, to lib.Address, amount *big.Int) (bool, *lib.ExecutionResult, error)")
+	require.Contains(t, string(code), "func (c *Token) Memo(api *lib.GoAPI, text *string) (*lib.ExecutionResult, error)")
+	require.Contains(t, string(code), "func DecodeTokenTransferEvent(eventArgs [][]byte) (*TokenTransferEvent, error)")
+
+	_, err = gen.Generate(contractABI, gen.Options{})
+	require.Error(t, err)
+}
+
+func TestGenerateClientFieldNames(t *testing.T) {
+	contractABI, err := abi.Parse([]byte(`{
+  "name": "registry",
+  "methods": [
+    {"name": "address", "output": "address", "view": true},
+    {"name": "code", "output": "bytes", "view": true},
+    {"name": "gasLimit", "inputs": [{"name": "value", "type": "u64"}]}
+  ]
+}`))
+	require.NoError(t, err)
+
+	code, err := gen.Generate(contractABI, gen.Options{Package: "registry"})
+	require.NoError(t, err)
+
+	typeCheck(t, code)
+	require.Contains(t, string(code), "func (c *Registry) Address(api *lib.GoAPI) (lib.Address, *lib.ExecutionResult, error)")
+}
+
+func TestGenerateClientNameCollisions(t *testing.T) {
+	cases := []struct {
+		abi string
+		err string
+	}{
+		{`{"name": "c", "methods": [{"name": "Deploy"}]}`, "method Deploy collides with the deploy method as Deploy"},
+		{`{"name": "c", "methods": [{"name": "foo_bar"}, {"name": "fooBar"}]}`, "method fooBar collides with method foo_bar as FooBar"},
+		{`{"name": "c", "methods": [{"name": "_"}]}`, "method _ has no Go name"},
+		{`{"name": "c", "events": [{"name": "foo-bar"}, {"name": "FooBar"}]}`, "event FooBar collides with event foo-bar as CFooBarEvent"},
+		{`{"name": "c", "events": [{"name": "_"}]}`, "event _ has no Go name"},
+		{`{"name": "c", "events": [{"name": "e", "args": [{"name": "to_addr", "type": "address"}, {"name": "toAddr", "type": "address"}]}]}`,
+			"event e argument toAddr collides with event e argument to_addr as ToAddr"},
+		{`{"name": "c", "events": [{"name": "e", "args": [{"type": "u8"}, {"name": "arg0", "type": "u8"}]}]}`,
+			"event e argument arg0 collides with event e argument 0 as Arg0"},
+	}
+	for _, c := range cases {
+		contractABI, err := abi.Parse([]byte(c.abi))
+		require.NoError(t, err)
+		_, err = gen.Generate(contractABI, gen.Options{Package: "c"})
+		require.EqualError(t, err, c.err, c.abi)
+	}
+
+	// the deploy method itself is generated as Deploy
+	contractABI, err := abi.Parse([]byte(`{"name": "c", "methods": [{"name": "deploy"}, {"name": "foo_bar"}], "events": [{"name": "foo_bar"}]}`))
+	require.NoError(t, err)
+	code, err := gen.Generate(contractABI, gen.Options{Package: "c"})
+	require.NoError(t, err)
+	typeCheck(t, code)
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/args"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestArgsRoundTrip(t *testing.T) {
+	amount, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	packed := args.NewBuilder().
+		U8(1).
+		U16(2).
+		U32(3).
+		U64(4).
+		I64(-5).
+		Bool(true).
+		String("idena").
+		Address(lib.Address{0x1, 0x2}).
+		BigInt(amount).
+		Raw([]byte{0xff}).
+		Nil().
+		Pack()
+	require.Equal(t, byte(lib.ArgsProtobufFormat), packed[0])
+
+	d := args.Unpack(packed)
+	require.Equal(t, 11, d.Len())
+	require.Equal(t, uint8(1), d.U8())
+	require.Equal(t, uint16(2), d.U16())
+	require.Equal(t, uint32(3), d.U32())
+	require.Equal(t, uint64(4), d.U64())
+	require.Equal(t, int64(-5), d.I64())
+	require.True(t, d.Bool())
+	require.Equal(t, "idena", d.String())
+	require.Equal(t, lib.Address{0x1, 0x2}, d.Address())
+	require.Equal(t, amount, d.BigInt())
+	require.Equal(t, []byte{0xff}, d.Raw())
+	require.True(t, d.IsNil())
+	require.Nil(t, d.Raw())
+	require.NoError(t, d.Err())
+
+	d.U64()
+	require.ErrorIs(t, d.Err(), args.ErrNoArgument)
+
+	d = args.NewDecoder([][]byte{{}, nil})
+	require.Equal(t, []byte{}, d.Bytes())
+	d.Bytes()
+	require.ErrorIs(t, d.Err(), args.ErrNilArgument)
+}
+
+func TestArgsEmptyValuesRoundTrip(t *testing.T) {
+	packed := args.NewBuilder().
+		BigInt(big.NewInt(0)).
+		String("").
+		Nil().
+		Pack()
+
+	d := args.Unpack(packed)
+	require.Equal(t, 3, d.Len())
+	require.Equal(t, 0, d.BigInt().Sign())
+	require.Equal(t, "", d.String())
+	require.True(t, d.IsNil())
+	require.NoError(t, d.Err())
+
+	d = args.Unpack(packed)
+	require.Equal(t, []byte{}, d.Raw())
+	require.Equal(t, []byte{}, d.Raw())
+	require.Nil(t, d.Raw())
+
+	// lib.UnpackArguments returns present empty arguments as nil
+	require.Equal(t, [][]byte{nil, nil, nil}, lib.UnpackArguments(packed))
+}
+
+func TestArgsNilAmount(t *testing.T) {
+	encoded, err := args.Encode((*big.Int)(nil))
+	require.NoError(t, err)
+	require.Nil(t, encoded)
+	require.Nil(t, args.BigInt(nil))
+	require.Equal(t, []byte{}, args.BigInt(big.NewInt(0)))
+}
+
+func TestArgsEncodeMatchesSdk(t *testing.T) {
+	require.Equal(t, ToBytes(uint64(10)), args.U64(10))
+	require.Equal(t, ToBytes(int64(-10)), args.I64(-10))
+
+	encoded, err := args.EncodeAll(uint32(7), "a", nil, big.NewInt(256))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{7, 0, 0, 0}, []byte("a"), nil, {1, 0}}, encoded)
+
+	_, err = args.Encode(1.5)
+	require.Error(t, err)
+
+	d := args.NewDecoder([][]byte{{1, 2}})
+	d.U32()
+	require.ErrorIs(t, d.Err(), args.ErrInvalidLength)
+}
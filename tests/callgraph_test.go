@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-wasm-binding/callgraph"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestCallGraph(t *testing.T) {
+	result := &lib.ExecutionResult{
+		Action:   &lib.Action{Type: lib.ActionFunctionCall, Method: "transfer", GasLimit: 1000, Amount: big.NewInt(5)},
+		Contract: lib.Address{0x1},
+		GasUsed:  700,
+		Error:    `sub call "pay" failed`,
+		SubResults: []*lib.ExecutionResult{
+			{
+				Action:   &lib.Action{Type: lib.ActionFunctionCall, Method: "pay", GasLimit: 500},
+				Contract: lib.Address{0x2},
+				GasUsed:  500,
+				Error:    "out of gas",
+			},
+		},
+	}
+
+	root := callgraph.Build(result)
+	require.Equal(t, "5", root.Amount)
+	require.Len(t, root.Calls, 1)
+	require.Equal(t, 1, root.Calls[0].ID)
+	require.Equal(t, "pay", root.Calls[0].Method)
+
+	data, err := callgraph.JSON(result)
+	require.NoError(t, err)
+	parsed := &callgraph.Node{}
+	require.NoError(t, json.Unmarshal(data, parsed))
+	require.Equal(t, root, parsed)
+
+	dot := callgraph.DOT(result)
+	require.Contains(t, dot, "n0 -> n1;")
+	require.Contains(t, dot, `failed: sub call \"pay\" failed`)
+	require.Contains(t, dot, "gas 500 / 500")
+
+	mermaid := callgraph.Mermaid(result)
+	require.Contains(t, mermaid, "n0 --> n1")
+	require.Contains(t, mermaid, "#quot;pay#quot;")
+	require.Contains(t, mermaid, "class n0,n1 failed")
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv/dbstore"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	db "github.com/tendermint/tm-db"
+	"math/big"
+	"testing"
+)
+
+func TestDbStore(t *testing.T) {
+	caller, contract := lib.Address{0x1}, lib.Address{0x2}
+	database := db.NewMemDB()
+	store := dbstore.NewStore(database)
+	store.SetBalance(caller, big.NewInt(100))
+	store.SetStorage(contract, []byte("old"), []byte{0x1})
+	require.NoError(t, store.Flush())
+
+	code := []byte{0x0, 0x61, 0x73, 0x6d}
+	env := memory.NewEnv(store, &memory.Block{}, memory.Context{Caller: caller, Contract: contract})
+	meter := &lib.GasMeter{}
+	require.NoError(t, env.Transfer(caller, contract, big.NewInt(30)))
+	env.Deploy(code)
+	env.SetStorage(meter, []byte("k"), []byte("v"))
+	env.RemoveStorage(meter, []byte("old"))
+	env.Commit()
+
+	// the committed changes are visible before the flush but are not written to the database
+	require.Equal(t, big.NewInt(70), store.Balance(caller))
+	value, err := database.Get(dbstore.StorageKey(contract, []byte("k")))
+	require.NoError(t, err)
+	require.Nil(t, value)
+	require.NoError(t, store.Flush())
+
+	reopened := dbstore.NewStore(database)
+	require.Equal(t, big.NewInt(70), reopened.Balance(caller))
+	require.Equal(t, big.NewInt(30), reopened.Balance(contract))
+	require.Equal(t, []byte("v"), reopened.GetStorage(contract, []byte("k")))
+	require.Nil(t, reopened.GetStorage(contract, []byte("old")))
+	require.Equal(t, code, reopened.Code(reopened.ContractCodeHash(contract)))
+
+	keys, err := reopened.StorageKeys(contract)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("k")}, keys)
+	contracts, err := reopened.Contracts()
+	require.NoError(t, err)
+	require.Equal(t, []lib.Address{contract}, contracts)
+
+	reopened.SetMeta("height", []byte{0x7})
+	reopened.Discard()
+	require.Nil(t, reopened.Meta("height"))
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestDiffHostEnv(t *testing.T) {
+	state := memory.NewState()
+	state.SetBalance(lib.Address{0x1}, big.NewInt(100))
+	env := hostenv.NewDiffHostEnv(memory.NewEnv(state, &memory.Block{}, memory.Context{Contract: lib.Address{0x1}}), lib.Address{0x1})
+	meter := &lib.GasMeter{}
+
+	env.SetStorage(meter, []byte("b"), []byte{0x2})
+	env.SetStorage(meter, []byte("a"), []byte{0x1})
+	env.RemoveStorage(meter, []byte("b"))
+	require.NoError(t, env.Burn(meter, big.NewInt(5)))
+
+	sub, err := env.CreateSubEnv(lib.Address{0x2}, "inc", big.NewInt(10), false)
+	require.NoError(t, err)
+	sub.AddBalance(meter, lib.Address{0x3}, big.NewInt(4))
+	require.NoError(t, sub.SubBalance(meter, big.NewInt(4)))
+	sub.Commit()
+	env.Commit()
+
+	failed, err := env.CreateSubEnv(lib.Address{0x4}, "fail", big.NewInt(0), false)
+	require.NoError(t, err)
+	failed.SetStorage(meter, []byte("c"), []byte{0x3})
+
+	diff := env.StateDiff()
+	require.Equal(t, []hostenv.StorageWrite{
+		{Contract: addressBytes(lib.Address{0x1}), Key: hostenv.Bytes("a"), Value: hostenv.Bytes{0x1}},
+		{Contract: addressBytes(lib.Address{0x1}), Key: hostenv.Bytes("b"), Removed: true},
+	}, diff.Storage)
+	require.Equal(t, []hostenv.BalanceChange{
+		{Address: addressBytes(lib.Address{0x1}), Delta: big.NewInt(-15)},
+		{Address: addressBytes(lib.Address{0x2}), Delta: big.NewInt(6)},
+		{Address: addressBytes(lib.Address{0x3}), Delta: big.NewInt(4)},
+	}, diff.Balances)
+	require.Equal(t, big.NewInt(5), diff.Burnt)
+	require.Empty(t, diff.Deployed)
+}
+
+func TestDiffHostEnvRevertDiscardsMergedSubEnv(t *testing.T) {
+	state := memory.NewState()
+	state.SetBalance(lib.Address{0x1}, big.NewInt(100))
+	env := hostenv.NewDiffHostEnv(memory.NewEnv(state, &memory.Block{}, memory.Context{Contract: lib.Address{0x1}}), lib.Address{0x1})
+	meter := &lib.GasMeter{}
+
+	env.SetStorage(meter, []byte("a"), []byte{0x1})
+	sub, err := env.CreateSubEnv(lib.Address{0x2}, "call", big.NewInt(10), false)
+	require.NoError(t, err)
+	subSub, err := sub.CreateSubEnv(lib.Address{0x3}, "call", big.NewInt(0), false)
+	require.NoError(t, err)
+	subSub.SetStorage(meter, []byte("b"), []byte{0x2})
+	// committing the nested call merges the calling sub env into the root early, repeated commits are no-ops
+	subSub.Commit()
+	sub.Commit()
+	sub.Commit()
+	require.Len(t, env.StateDiff().Storage, 2)
+
+	require.NoError(t, sub.(lib.RevertibleHostEnv).Revert())
+	diff := env.StateDiff()
+	require.Equal(t, []hostenv.StorageWrite{
+		{Contract: addressBytes(lib.Address{0x1}), Key: hostenv.Bytes("a"), Value: hostenv.Bytes{0x1}},
+	}, diff.Storage)
+	require.Empty(t, diff.Balances)
+}
+
+func TestDiffHostEnvTransfer(t *testing.T) {
+	state := memory.NewState()
+	state.SetBalance(lib.Address{0x1}, big.NewInt(100))
+	env := hostenv.NewDiffHostEnv(memory.NewEnv(state, &memory.Block{}, memory.Context{Contract: lib.Address{0x2}}), lib.Address{0x2})
+
+	require.NoError(t, env.Transfer(lib.Address{0x1}, lib.Address{0x2}, big.NewInt(30)))
+	require.Error(t, env.Transfer(lib.Address{0x1}, lib.Address{0x2}, big.NewInt(100)))
+	require.Equal(t, []hostenv.BalanceChange{
+		{Address: addressBytes(lib.Address{0x1}), Delta: big.NewInt(-30)},
+		{Address: addressBytes(lib.Address{0x2}), Delta: big.NewInt(30)},
+	}, env.StateDiff().Balances)
+}
+
+func addressBytes(addr lib.Address) hostenv.Bytes {
+	return addr[:]
+}
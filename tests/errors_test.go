@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestOutOfGasError(t *testing.T) {
+	meter := &lib.GasMeter{}
+	meter.SetRemainingGas(10)
+
+	defer func() {
+		rec := recover()
+		err, ok := rec.(error)
+		require.True(t, ok)
+		require.True(t, errors.Is(err, lib.ErrOutOfGas))
+		require.Equal(t, "out of gas", err.Error())
+	}()
+	meter.ConsumeGas(11)
+}
+
+func TestExecutionErrorMatching(t *testing.T) {
+	var err error = &lib.ExecutionError{
+		Kind:    lib.ErrCodeEmpty,
+		Message: "code is empty",
+		GasUsed: 100,
+		Path:    []int{0, 2},
+	}
+	require.True(t, errors.Is(err, lib.ErrCodeEmpty))
+	require.False(t, errors.Is(err, lib.ErrOutOfGas))
+
+	var executionErr *lib.ExecutionError
+	require.True(t, errors.As(err, &executionErr))
+	require.Equal(t, uint64(100), executionErr.GasUsed)
+	require.Equal(t, []int{0, 2}, executionErr.Path)
+}
+
+func TestExecuteContextDone(t *testing.T) {
+	env := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{})
+	api := lib.NewGoAPI(env, &lib.GasMeter{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gas, _, err := lib.ExecuteContext(ctx, api, []byte{0x1}, "inc", nil, lib.Address{0x1}, 1000, false)
+	require.Zero(t, gas)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.True(t, errors.Is(err, lib.ErrCanceled))
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, _, err = lib.DeployContext(ctx, api, []byte{0x1}, nil, lib.Address{0x1}, 1000, false)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+// cancelingHostEnv cancels the execution context in the first storage access.
+type cancelingHostEnv struct {
+	lib.HostEnv
+	cancel context.CancelFunc
+	calls  int
+}
+
+func (e *cancelingHostEnv) SetStorage(meter *lib.GasMeter, key []byte, value []byte) {
+	e.calls++
+	e.cancel()
+	e.HostEnv.SetStorage(meter, key, value)
+}
+
+func (e *cancelingHostEnv) GetStorage(meter *lib.GasMeter, key []byte) []byte {
+	e.calls++
+	e.cancel()
+	return e.HostEnv.GetStorage(meter, key)
+}
+
+func TestExecuteContextCanceledAtNextCallback(t *testing.T) {
+	code, _ := testdata.Sum()
+	host := NewMockHostEnv()
+	_, _, err := lib.Deploy(lib.NewGoAPI(host, &lib.GasMeter{}), code, [][]byte{ToBytes(uint64(1))}, lib.Address{}, 10000000, true)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := &cancelingHostEnv{HostEnv: host, cancel: cancel}
+	_, _, err = lib.ExecuteContext(ctx, lib.NewGoAPI(env, &lib.GasMeter{}), code, "compute", [][]byte{ToBytes(uint64(10))}, lib.Address{}, 1000000, true)
+	require.True(t, errors.Is(err, lib.ErrCanceled))
+	require.True(t, errors.Is(err, context.Canceled))
+	// the storage access cancelling the context completes, the next one is not reached
+	require.Equal(t, 1, env.calls)
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEventSinks(t *testing.T) {
+	buffer := lib.NewEventBuffer()
+	buffer.Emit(&lib.ContractEvent{Name: "a", Index: 0})
+	buffer.Emit(&lib.ContractEvent{Name: "b", Index: 1, Depth: 1})
+	buffer.Emit(&lib.ContractEvent{Name: "c", Index: 2, Depth: 2})
+	buffer.Revert(1)
+	buffer.Emit(&lib.ContractEvent{Name: "d", Index: 1, Depth: 1})
+	require.Len(t, buffer.Events(), 2)
+	require.Equal(t, "d", buffer.Events()[1].Name)
+
+	channel := lib.NewEventChannel(3)
+	channel.Emit(&lib.ContractEvent{Name: "a"})
+	channel.Revert(0)
+	channel.Close()
+	var messages []lib.EventMessage
+	for msg := range channel.Messages() {
+		messages = append(messages, msg)
+	}
+	require.Len(t, messages, 2)
+	require.Equal(t, "a", messages[0].Event.Name)
+	require.Nil(t, messages[1].Event)
+	require.Equal(t, 0, messages[1].RevertIndex)
+}
+
+func TestEventIndexPerExecution(t *testing.T) {
+	code, _ := testdata.Events()
+	env := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{Contract: lib.Address{0x1}})
+	api := lib.NewGoAPI(env, &lib.GasMeter{})
+	channel := lib.NewEventChannel(4)
+	api.SetEventSink(channel)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := lib.Execute(api, code, "emit", nil, lib.Address{0x1}, 100000, false)
+		require.NoError(t, err)
+	}
+	channel.Close()
+	var indexes []int
+	for msg := range channel.Messages() {
+		require.NotNil(t, msg.Event)
+		require.Equal(t, "emit", msg.Event.Name)
+		indexes = append(indexes, msg.Event.Index)
+	}
+	require.Equal(t, []int{0, 1, 0, 1}, indexes)
+}
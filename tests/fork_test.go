@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestForkConfigRules(t *testing.T) {
+	upgraded := lib.DefaultGasSchedule()
+	upgraded.Version = 2
+	upgraded.Costs[lib.HostSetStorage] = lib.GasCost{Base: 200, PerByte: 20}
+	config := &lib.ForkConfig{
+		Schedule: lib.DefaultGasSchedule(),
+		Forks: []lib.Fork{
+			{Name: "crypto", Height: 100, Enable: []lib.HostFunction{lib.HostEcrecover, lib.HostBurn}},
+			{Name: "storage", Height: 200, Epoch: 5, Schedule: upgraded},
+		},
+	}
+	require.NoError(t, config.Validate())
+
+	rules := config.Rules(99, 0)
+	require.False(t, rules.IsEnabled(lib.HostEcrecover))
+	require.False(t, rules.IsEnabled(lib.HostBurn))
+	require.True(t, rules.IsEnabled(lib.HostSetStorage))
+	require.Equal(t, uint32(1), rules.Schedule.Version)
+
+	rules = config.Rules(200, 4)
+	require.True(t, rules.IsEnabled(lib.HostEcrecover))
+	require.Equal(t, uint32(1), rules.Schedule.Version)
+
+	rules = config.Rules(200, 5)
+	require.Equal(t, upgraded, rules.Schedule)
+
+	_, err := lib.ParseForkConfig([]byte(`{"forks":[{"name":"a","height":10},{"name":"b","height":5}]}`))
+	require.Error(t, err)
+	_, err = lib.ParseForkConfig([]byte(`{"forks":[{"name":"a","enable":["burn"]},{"name":"b","enable":["burn"]}]}`))
+	require.Error(t, err)
+	parsed, err := lib.ParseForkConfig([]byte(`{"forks":[{"name":"a","height":10,"enable":["ecrecover"]}]}`))
+	require.NoError(t, err)
+	require.False(t, parsed.Rules(9, 0).IsEnabled(lib.HostEcrecover))
+	require.Nil(t, parsed.Rules(10, 0).Schedule)
+
+	// height and epoch forks are ordered independently
+	_, err = lib.ParseForkConfig([]byte(`{"forks":[{"name":"a","epoch":5},{"name":"b","height":100},{"name":"c","epoch":6}]}`))
+	require.NoError(t, err)
+	_, err = lib.ParseForkConfig([]byte(`{"forks":[{"name":"a","epoch":5},{"name":"b","height":100},{"name":"c","epoch":4}]}`))
+	require.Error(t, err)
+}
+
+func TestForkConfigKeepsGasSchedule(t *testing.T) {
+	env := memory.NewEnv(memory.NewState(), &memory.Block{Number: 300}, memory.Context{})
+	schedule := lib.DefaultGasSchedule()
+	api := lib.NewGoAPIWithGasSchedule(env, &lib.GasMeter{}, schedule)
+	upgraded := lib.DefaultGasSchedule()
+	upgraded.Version = 2
+	api.SetForkConfig(&lib.ForkConfig{Forks: []lib.Fork{{Name: "upgrade", Height: 200, Schedule: upgraded}}})
+
+	_, _, _ = lib.Execute(api, []byte{0x1}, "inc", nil, lib.Address{0x1}, 1000, false)
+	require.Same(t, schedule, api.GasSchedule())
+}
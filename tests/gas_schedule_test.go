@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGasSchedule(t *testing.T) {
+	schedule := lib.DefaultGasSchedule()
+	require.NoError(t, schedule.Validate())
+	for _, fn := range lib.HostFunctions {
+		require.Contains(t, schedule.Costs, fn)
+	}
+	require.Equal(t, uint64(100+10*5), schedule.Cost(lib.HostSetStorage).Cost(5))
+
+	data, err := schedule.Marshal()
+	require.NoError(t, err)
+	parsed, err := lib.ParseGasSchedule(data)
+	require.NoError(t, err)
+	require.Equal(t, schedule, parsed)
+
+	_, err = lib.ParseGasSchedule([]byte(`{"version":2,"costs":{"unknown":{"base":1}}}`))
+	require.Error(t, err)
+
+	var empty *lib.GasSchedule
+	require.Zero(t, empty.Cost(lib.HostCall).Cost(100))
+}
+
+func TestGasScheduleIsOptIn(t *testing.T) {
+	env := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{})
+	require.Nil(t, lib.NewGoAPI(env, &lib.GasMeter{}).GasSchedule())
+
+	schedule := lib.DefaultGasSchedule()
+	require.Equal(t, schedule, lib.NewGoAPIWithGasSchedule(env, &lib.GasMeter{}, schedule).GasSchedule())
+}
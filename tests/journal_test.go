@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestJournalHostEnv(t *testing.T) {
+	caller, callee, nested := lib.Address{0x1}, lib.Address{0x2}, lib.Address{0x3}
+	store := memory.NewState()
+	store.SetBalance(caller, big.NewInt(100))
+	block := &memory.Block{Number: 7}
+	base := memory.NewEnv(memory.NewState(), block, memory.Context{})
+	env := hostenv.NewJournalHostEnv(base, store, memory.Context{Contract: caller})
+	meter := &lib.GasMeter{}
+
+	require.Equal(t, uint64(7), env.BlockNumber(meter))
+	env.SetStorage(meter, []byte("k"), []byte("root"))
+
+	// the binding reverts a failed sub call at once
+	failed, err := env.CreateSubEnv(callee, "fail", big.NewInt(10), false)
+	require.NoError(t, err)
+	failed.SetStorage(meter, []byte("k"), []byte("failed"))
+	failed.Event(meter, "failed")
+	require.NoError(t, failed.Burn(meter, big.NewInt(4)))
+	require.Equal(t, big.NewInt(90), env.Journal().Balance(caller))
+	require.Equal(t, big.NewInt(4), env.Journal().Burnt())
+	require.NoError(t, failed.(lib.RevertibleHostEnv).Revert())
+	require.Equal(t, big.NewInt(100), env.Journal().Balance(caller))
+	require.Zero(t, env.Journal().Burnt().Sign())
+	require.Equal(t, big.NewInt(100), env.Balance(meter))
+	require.Empty(t, env.Journal().Events())
+	require.Nil(t, env.ReadContractData(meter, callee, []byte("k")))
+
+	// committed sub calls stay in the journal until the root env commits
+	sub, err := env.CreateSubEnv(callee, "ok", big.NewInt(20), false)
+	require.NoError(t, err)
+	sub.SetStorage(meter, []byte("k"), []byte("sub"))
+	subSub, err := sub.CreateSubEnv(nested, "inner", big.NewInt(5), false)
+	require.NoError(t, err)
+	subSub.Event(meter, "inner")
+	subSub.Commit()
+	sub.Commit()
+	require.Nil(t, store.GetStorage(callee, []byte("k")))
+
+	env.Commit()
+	require.Equal(t, []byte("root"), store.GetStorage(caller, []byte("k")))
+	require.Equal(t, []byte("sub"), store.GetStorage(callee, []byte("k")))
+	require.Equal(t, big.NewInt(80), store.Balance(caller))
+	require.Equal(t, big.NewInt(15), store.Balance(callee))
+	require.Equal(t, big.NewInt(5), store.Balance(nested))
+	require.Len(t, env.Journal().Events(), 1)
+	require.Equal(t, nested, env.Journal().Events()[0].Contract)
+}
+
+func TestJournalHostEnvRevertAfterCommit(t *testing.T) {
+	contract := lib.Address{0x1}
+	store := memory.NewState()
+	base := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{})
+	env := hostenv.NewJournalHostEnv(base, store, memory.Context{Contract: contract})
+	meter := &lib.GasMeter{}
+
+	env.SetStorage(meter, []byte("a"), []byte("1"))
+	env.Commit()
+	env.SetStorage(meter, []byte("a"), []byte("2"))
+	env.SetStorage(meter, []byte("b"), []byte("3"))
+	require.NoError(t, env.Revert())
+
+	require.Equal(t, []byte("1"), env.GetStorage(meter, []byte("a")))
+	require.Nil(t, env.GetStorage(meter, []byte("b")))
+	env.Commit()
+	require.Equal(t, []byte("1"), store.GetStorage(contract, []byte("a")))
+	require.Nil(t, store.GetStorage(contract, []byte("b")))
+}
+
+func TestJournalRevert(t *testing.T) {
+	store := memory.NewState()
+	journal := hostenv.NewJournal(store)
+	contract := lib.Address{0x1}
+
+	journal.SetStorage(contract, []byte("a"), []byte{0x1})
+	savepoint := journal.Savepoint()
+	journal.SetStorage(contract, []byte("a"), []byte{0x2})
+	journal.SetStorage(contract, []byte("b"), []byte{0x3})
+	journal.SetBalance(contract, big.NewInt(5))
+	require.NoError(t, journal.Revert(savepoint))
+
+	require.Equal(t, []byte{0x1}, journal.GetStorage(contract, []byte("a")))
+	require.Nil(t, journal.GetStorage(contract, []byte("b")))
+	require.Equal(t, big.NewInt(0), journal.Balance(contract))
+	journal.Commit()
+	require.Equal(t, []byte{0x1}, store.GetStorage(contract, []byte("a")))
+	require.Len(t, store.StorageKeys(contract), 1)
+
+	// savepoints taken before a commit are invalid
+	journal.SetStorage(contract, []byte("c"), []byte{0x4})
+	require.ErrorIs(t, journal.Revert(savepoint), hostenv.ErrInvalidSavepoint)
+	require.ErrorIs(t, journal.Revert(journal.Savepoint()+1), hostenv.ErrInvalidSavepoint)
+	require.Equal(t, []byte{0x4}, journal.GetStorage(contract, []byte("c")))
+}
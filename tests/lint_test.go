@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/idena-network/idena-wasm-binding/wasm"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func lintRules(t *testing.T, code []byte, config *wasm.LintConfig) map[wasm.Rule]wasm.Severity {
+	report, err := wasm.Lint(code, config)
+	require.NoError(t, err)
+	res := map[wasm.Rule]wasm.Severity{}
+	for _, f := range report.Findings {
+		res[f.Rule] = f.Severity
+	}
+	return res
+}
+
+func TestLint(t *testing.T) {
+	clean := testContract(nil, [][]byte{{0, 0x0b}})
+	require.Empty(t, lintRules(t, clean, nil))
+
+	// loop { i32.const 1, memory.grow, drop }, f64.const 0, drop
+	body := []byte{0, 0x03, 0x40, 0x41, 1, 0x40, 0, 0x1a, 0x0b, 0x44, 0, 0, 0, 0, 0, 0, 0, 0, 0x1a, 0x0b}
+	require.Equal(t, map[wasm.Rule]wasm.Severity{
+		wasm.RuleMemoryGrowLoop: wasm.SeverityWarning,
+		wasm.RuleFloat:          wasm.SeverityError,
+	}, lintRules(t, testContract(nil, [][]byte{body}), nil))
+
+	// memory.grow outside of loops is fine
+	body = []byte{0, 0x03, 0x40, 0x0b, 0x41, 1, 0x40, 0, 0x1a, 0x0b}
+	require.Empty(t, lintRules(t, testContract(nil, [][]byte{body}), nil))
+
+	// deploy calls own_addr and a helper which calls create_call_function_promise
+	deploy := []byte{0, 0x41, 0, 0x41, 0, 0x10, 0, 0x10, 3, 0x0b}
+	helper := []byte{0, 0x41, 0, 0x41, 0, 0x10, 1, 0x0b}
+	code := testContract([]string{"own_addr", "create_call_function_promise"}, [][]byte{deploy, helper})
+	require.Equal(t, map[wasm.Rule]wasm.Severity{wasm.RuleSelfCall: wasm.SeverityWarning}, lintRules(t, code, nil))
+	// vtable names are not VM imports
+	code = testContract([]string{"contract", "call"}, [][]byte{deploy, helper})
+	require.Empty(t, lintRules(t, code, nil))
+
+	data := wasmSection(wasm.SectionData, concat([]byte{1, 0, 0x41, 0, 0x0b, 100}, make([]byte, 100))...)
+	code = testContract(nil, [][]byte{{0, 0x0b}}, data)
+	require.Empty(t, lintRules(t, code, nil))
+	require.Equal(t, map[wasm.Rule]wasm.Severity{wasm.RuleLargeData: wasm.SeverityWarning},
+		lintRules(t, code, &wasm.LintConfig{MaxDataSegment: 64, MaxData: 1024}))
+
+	// a SIMD instruction cannot be decoded
+	report, err := wasm.Lint(testContract(nil, [][]byte{{0, 0xfd, 0x0c, 0x0b}}), nil)
+	require.NoError(t, err)
+	require.Len(t, report.Filter(wasm.SeverityError), 1)
+	require.Equal(t, wasm.RuleUnsupportedCode, report.Findings[0].Rule)
+
+	sum, err := testdata.Sum()
+	require.NoError(t, err)
+	report, err = wasm.Lint(sum, nil)
+	require.NoError(t, err)
+	require.Empty(t, report.Filter(wasm.SeverityError))
+	// sub_compute_promise creates a call promise to its own address
+	require.Len(t, report.Findings, 1)
+	require.Equal(t, wasm.RuleSelfCall, report.Findings[0].Rule)
+	require.Contains(t, report.Findings[0].Message, "sub_compute_promise")
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestMemoryEnvNestedCommit(t *testing.T) {
+	state := memory.NewState()
+	state.SetBalance(lib.Address{0x1}, big.NewInt(100))
+	env := memory.NewEnv(state, &memory.Block{Number: 10}, memory.Context{
+		Caller:         lib.Address{0x2},
+		OriginalCaller: lib.Address{0x2},
+		Contract:       lib.Address{0x1},
+	})
+	meter := &lib.GasMeter{}
+
+	env.SetStorage(meter, []byte("a"), []byte("1"))
+
+	sub, err := env.CreateSubEnv(lib.Address{0x3}, "inc", big.NewInt(30), false)
+	require.NoError(t, err)
+	require.Equal(t, lib.Address{0x1}, sub.Caller(meter))
+	require.Equal(t, big.NewInt(30), sub.Balance(meter))
+	require.Equal(t, big.NewInt(30), sub.PayAmount(meter))
+	sub.SetStorage(meter, []byte("b"), []byte("2"))
+	sub.Event(meter, "inc", []byte{0x1})
+
+	// successful sub call merged into the calling env
+	sub.Commit()
+	require.Nil(t, state.GetStorage(lib.Address{0x3}, []byte("b")))
+	require.Equal(t, []byte("2"), env.ReadContractData(meter, lib.Address{0x3}, []byte("b")))
+
+	failed, err := env.CreateSubEnv(lib.Address{0x4}, "fail", big.NewInt(10), false)
+	require.NoError(t, err)
+	failed.SetStorage(meter, []byte("c"), []byte("3"))
+	require.Equal(t, big.NewInt(70), env.BalanceOf(lib.Address{0x1}))
+	require.NoError(t, failed.(lib.RevertibleHostEnv).Revert())
+
+	_, err = env.CreateSubEnv(lib.Address{0x4}, "fail", big.NewInt(1000), false)
+	require.ErrorIs(t, err, memory.ErrInsufficientBalance)
+
+	env.Commit()
+	require.Equal(t, []byte("1"), state.GetStorage(lib.Address{0x1}, []byte("a")))
+	require.Equal(t, []byte("2"), state.GetStorage(lib.Address{0x3}, []byte("b")))
+	require.Nil(t, state.GetStorage(lib.Address{0x4}, []byte("c")))
+	require.Equal(t, big.NewInt(70), state.Balance(lib.Address{0x1}))
+	require.Equal(t, big.NewInt(30), state.Balance(lib.Address{0x3}))
+	require.Equal(t, []memory.Event{{Contract: lib.Address{0x3}, Name: "inc", Args: [][]byte{{0x1}}}}, env.Events())
+}
+
+func TestMemoryEnvDeploy(t *testing.T) {
+	state := memory.NewState()
+	env := memory.NewEnv(state, &memory.Block{}, memory.Context{Contract: lib.Address{0x1}})
+	meter := &lib.GasMeter{}
+	code := []byte{0x0, 0x61, 0x73, 0x6d}
+
+	addr := env.ContractAddr(meter, code, []byte{0x1}, []byte{0x2})
+	require.Nil(t, env.ContractCodeHash(addr))
+
+	sub, err := env.CreateSubEnv(addr, "deploy", big.NewInt(0), true)
+	require.NoError(t, err)
+	sub.Deploy(code)
+	require.Equal(t, code, sub.OwnCode(meter))
+	require.Equal(t, addr, env.ContractAddrByHash(meter, sub.CodeHash(meter), []byte{0x1}, []byte{0x2}))
+	sub.Commit()
+	env.Commit()
+
+	require.Equal(t, code, state.Code(state.ContractCodeHash(addr)))
+	require.Equal(t, []lib.Address{addr}, state.Contracts())
+}
+
+func TestMemoryEnvCommitIdempotent(t *testing.T) {
+	state := memory.NewState()
+	state.SetBalance(lib.Address{0x1}, big.NewInt(100))
+	env := memory.NewEnv(state, &memory.Block{}, memory.Context{Contract: lib.Address{0x1}})
+	meter := &lib.GasMeter{}
+
+	sub, err := env.CreateSubEnv(lib.Address{0x2}, "call", big.NewInt(10), false)
+	require.NoError(t, err)
+	sub.SetStorage(meter, []byte("a"), []byte("1"))
+	sub.Commit()
+	sub.Commit()
+	env.SetStorage(meter, []byte("b"), []byte("2"))
+	env.Commit()
+	env.Commit()
+
+	require.Equal(t, []byte("1"), state.GetStorage(lib.Address{0x2}, []byte("a")))
+	require.Equal(t, []byte("2"), state.GetStorage(lib.Address{0x1}, []byte("b")))
+	require.Equal(t, big.NewInt(90), state.Balance(lib.Address{0x1}))
+	require.Equal(t, big.NewInt(10), state.Balance(lib.Address{0x2}))
+}
+
+func TestMemoryEnvRevertDiscardsMergedSubEnv(t *testing.T) {
+	state := memory.NewState()
+	state.SetBalance(lib.Address{0x1}, big.NewInt(100))
+	env := memory.NewEnv(state, &memory.Block{}, memory.Context{Contract: lib.Address{0x1}})
+	meter := &lib.GasMeter{}
+
+	sub, err := env.CreateSubEnv(lib.Address{0x2}, "call", big.NewInt(10), false)
+	require.NoError(t, err)
+	subSub, err := sub.CreateSubEnv(lib.Address{0x3}, "call", big.NewInt(5), false)
+	require.NoError(t, err)
+	subSub.SetStorage(meter, []byte("a"), []byte("1"))
+	subSub.Event(meter, "called")
+
+	// a successful nested call as committed by ccall merges the calling sub env into the root early
+	subSub.Commit()
+	sub.Commit()
+	require.Equal(t, []byte("1"), env.ReadContractData(meter, lib.Address{0x3}, []byte("a")))
+
+	// the calling sub env fails later and discards the merged changes
+	require.NoError(t, sub.(lib.RevertibleHostEnv).Revert())
+	require.Nil(t, env.ReadContractData(meter, lib.Address{0x3}, []byte("a")))
+	require.Equal(t, big.NewInt(100), env.BalanceOf(lib.Address{0x1}))
+
+	env.Commit()
+	require.Nil(t, state.GetStorage(lib.Address{0x3}, []byte("a")))
+	require.Equal(t, big.NewInt(100), state.Balance(lib.Address{0x1}))
+	require.Empty(t, env.Events())
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/wasm"
+	"github.com/idena-network/idena-wasm-binding/wasminfo"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	code := testContract([]string{"set_storage"}, [][]byte{{0, 0x0b}})
+	_, err := abi.ExtractMetadata(code)
+	require.ErrorIs(t, err, abi.ErrNoMetadata)
+
+	metadata := &abi.Metadata{
+		Name:       "counter",
+		Version:    "1.0.0",
+		SourceHash: "0x0102",
+		ABI: &abi.ABI{Methods: []abi.Method{
+			{Name: "inc", Inputs: []abi.Argument{{Name: "by", Type: abi.TypeU64}}},
+		}},
+	}
+	embedded, err := abi.EmbedMetadata(code, metadata)
+	require.NoError(t, err)
+	require.True(t, wasm.Validate(embedded, nil).Valid())
+	extracted, err := abi.ExtractMetadata(embedded)
+	require.NoError(t, err)
+	require.Equal(t, metadata, extracted)
+
+	// embedding again replaces the section
+	metadata.Version = "1.0.1"
+	embedded, err = abi.EmbedMetadata(embedded, metadata)
+	require.NoError(t, err)
+	m, err := wasm.Parse(embedded)
+	require.NoError(t, err)
+	require.Len(t, m.CustomSections, 1)
+	info, err := wasminfo.Inspect(embedded)
+	require.NoError(t, err)
+	require.Equal(t, "1.0.1", info.Metadata.Version)
+
+	stripped, err := wasm.RemoveCustomSections(embedded, abi.MetadataSection)
+	require.NoError(t, err)
+	require.Equal(t, code, stripped)
+
+	_, err = abi.EmbedMetadata(code, &abi.Metadata{Name: "bad", SourceHash: "0102"})
+	require.Error(t, err)
+	info, err = wasminfo.Inspect(wasm.AppendCustomSection(code, abi.MetadataSection, []byte("{}")))
+	require.NoError(t, err)
+	require.Nil(t, info.Metadata)
+	require.NotEmpty(t, info.MetadataError)
+
+	contract := lib.Address{0x1}
+	env := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{Contract: contract, PayAmount: big.NewInt(0)})
+	env.Deploy(embedded)
+	env.Commit()
+	extracted, err = abi.ContractMetadata(env, contract)
+	require.NoError(t, err)
+	require.Equal(t, "counter", extracted.Name)
+	_, err = abi.ContractMetadata(env, lib.Address{0x2})
+	require.ErrorIs(t, err, lib.ErrCodeEmpty)
+}
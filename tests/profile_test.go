@@ -0,0 +1,30 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGasProfileExecutionResult(t *testing.T) {
+	caller := lib.Address{0x1}
+	callee := lib.Address{0x2}
+	result := &lib.ExecutionResult{
+		Contract: caller,
+		GasUsed:  1000,
+		SubResults: []*lib.ExecutionResult{
+			{Contract: callee, GasUsed: 300},
+			{Contract: callee, GasUsed: 200},
+		},
+	}
+	profile := lib.NewGasProfile(lib.DefaultStorageKeyPrefixLength)
+	profile.AddExecutionResult(result)
+
+	require.Equal(t, uint64(1000), profile.TotalGas)
+	require.Equal(t, uint64(1), profile.Contracts[caller].Executions)
+	require.Equal(t, uint64(500), profile.Contracts[caller].TotalGas)
+	require.Equal(t, uint64(500), profile.Contracts[caller].WasmGas())
+	require.Equal(t, uint64(2), profile.Contracts[callee].Executions)
+	require.Equal(t, uint64(500), profile.Contracts[callee].TotalGas)
+	require.Contains(t, profile.String(), "total gas: 1000")
+}
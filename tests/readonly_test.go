@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReadOnlyHostFunctions(t *testing.T) {
+	api := lib.NewGoAPI(memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{}), &lib.GasMeter{})
+	require.False(t, api.IsReadOnly())
+	api.SetReadOnly(true)
+	require.True(t, api.IsReadOnly())
+
+	var mutating []lib.HostFunction
+	for _, fn := range lib.HostFunctions {
+		if fn.IsMutating() {
+			mutating = append(mutating, fn)
+		}
+	}
+	require.ElementsMatch(t, []lib.HostFunction{
+		lib.HostSetStorage, lib.HostRemoveStorage, lib.HostAddBalance, lib.HostDeductBalance,
+		lib.HostBurn, lib.HostDeploy, lib.HostEvent,
+	}, mutating)
+	require.False(t, lib.HostCall.IsMutating())
+}
+
+func TestReadOnlyEvent(t *testing.T) {
+	code, _ := testdata.Events()
+	env := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{Contract: lib.Address{0x1}})
+	api := lib.NewGoAPI(env, &lib.GasMeter{})
+	api.SetReadOnly(true)
+	buffer := lib.NewEventBuffer()
+	api.SetEventSink(buffer)
+
+	_, _, err := lib.Execute(api, code, "emit", nil, lib.Address{0x1}, 100000, false)
+	require.ErrorIs(t, err, lib.ErrReadOnly)
+	require.Empty(t, buffer.Events())
+	env.Commit()
+	require.Empty(t, env.Events())
+}
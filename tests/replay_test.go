@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+// replayCalls makes the calls a contract with a sub call would make and returns what the env responded
+// followed by the gas consumed on the meter.
+func replayCalls(t *testing.T, env lib.HostEnv) []interface{} {
+	meter := &lib.GasMeter{}
+	callee := lib.Address{0x3}
+	var res []interface{}
+	res = append(res, env.GetStorage(meter, []byte("missing")), env.GetStorage(meter, []byte("k")))
+	env.SetStorage(meter, []byte("k"), []byte("new"))
+	res = append(res, env.BlockNumber(meter), env.Balance(meter), env.Caller(meter), env.Identity(meter, lib.Address{0x2}))
+	res = append(res, env.ContractCodeHash(callee), env.GetCode(callee))
+	sub, err := env.CreateSubEnv(callee, "inc", big.NewInt(5), false)
+	require.NoError(t, err)
+	res = append(res, sub.PayAmount(meter), sub.Epoch(meter))
+	sub.Event(meter, "inc", []byte{0x1})
+	res = append(res, sub.Burn(meter, big.NewInt(100)))
+	sub.Commit()
+	env.Commit()
+	return append(res, meter.GasConsumed())
+}
+
+func TestRecordReplay(t *testing.T) {
+	contract, caller, callee := lib.Address{0x1}, lib.Address{0x4}, lib.Address{0x3}
+	state := memory.NewState()
+	state.SetStorage(contract, []byte("k"), []byte("old"))
+	state.SetBalance(contract, big.NewInt(50))
+	state.SetIdentity(lib.Address{0x2}, []byte{0x7})
+	state.SetCode(memory.CodeHash([]byte("code")), []byte("code"))
+	state.SetContractCodeHash(callee, memory.CodeHash([]byte("code")))
+	block := &memory.Block{Number: 12, Epoch: 3}
+	env := memory.NewEnv(state, block, memory.Context{Caller: caller, Contract: contract, PayAmount: big.NewInt(0)})
+
+	recordingEnv := hostenv.NewRecordingHostEnv(env)
+	recorded := replayCalls(t, recordingEnv)
+	calls := recordingEnv.Recording().Calls
+	require.Equal(t, hostenv.RecordedCall{Depth: 1, Method: "Commit"}, calls[len(calls)-2])
+	require.Equal(t, hostenv.RecordedCall{Depth: 0, Method: "Commit"}, calls[len(calls)-1])
+	require.Error(t, recorded[len(recorded)-2].(error))
+	require.NotZero(t, recorded[len(recorded)-1])
+
+	data, err := recordingEnv.Recording().Marshal()
+	require.NoError(t, err)
+	recording, err := hostenv.ParseRecording(data)
+	require.NoError(t, err)
+
+	replay := hostenv.NewReplayHostEnv(recording)
+	replayed := replayCalls(t, replay)
+	require.NoError(t, replay.Verify())
+	require.Equal(t, len(recorded), len(replayed))
+	for i := range recorded {
+		if err, ok := recorded[i].(error); ok {
+			require.EqualError(t, replayed[i].(error), err.Error())
+			continue
+		}
+		require.Equal(t, recorded[i], replayed[i], "response %v", i)
+	}
+
+	// a call with different arguments diverges from the recording
+	replay = hostenv.NewReplayHostEnv(recording)
+	replay.GetStorage(&lib.GasMeter{}, []byte("missing"))
+	require.Panics(t, func() {
+		replay.GetStorage(&lib.GasMeter{}, []byte("other"))
+	})
+	var mismatch *hostenv.ReplayMismatch
+	require.ErrorAs(t, replay.Verify(), &mismatch)
+	require.Equal(t, 1, mismatch.Index)
+
+	// calls left in the recording are reported as well
+	replay = hostenv.NewReplayHostEnv(recording)
+	replay.GetStorage(&lib.GasMeter{}, []byte("missing"))
+	require.ErrorAs(t, replay.Verify(), &mismatch)
+	require.Nil(t, mismatch.Actual)
+}
+
+func TestReplayOutOfGas(t *testing.T) {
+	env := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{Contract: lib.Address{0x1}})
+	recordingEnv := hostenv.NewRecordingHostEnv(env)
+	meter := &lib.GasMeter{}
+	meter.SetRemainingGas(1)
+	require.PanicsWithValue(t, lib.OutOfGas{}, func() {
+		recordingEnv.SetStorage(meter, []byte("k"), []byte("v"))
+	})
+	call := recordingEnv.Recording().Calls[0]
+	require.True(t, call.OutOfGas)
+	require.Empty(t, call.Panic)
+	require.Equal(t, meter.GasConsumed(), call.GasUsed)
+
+	replay := hostenv.NewReplayHostEnv(recordingEnv.Recording())
+	replayMeter := &lib.GasMeter{}
+	require.PanicsWithValue(t, lib.OutOfGas{}, func() {
+		replay.SetStorage(replayMeter, []byte("k"), []byte("v"))
+	})
+	require.Equal(t, meter.GasConsumed(), replayMeter.GasConsumed())
+	require.NoError(t, replay.Verify())
+}
+
+func TestReplayExecutionGas(t *testing.T) {
+	code, _ := testdata.Events()
+	contract := lib.Address{0x1}
+	env := memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{Contract: contract})
+	recordingEnv := hostenv.NewRecordingHostEnv(env)
+	recording := recordingEnv.Recording()
+	recording.GasSchedule = lib.DefaultGasSchedule()
+	recording.Execution = &hostenv.RecordedExecution{Code: code, Method: "emit", Contract: contract[:], GasLimit: 100000}
+	recorded, err := recording.Run(recording.NewGoAPI(recordingEnv, &lib.GasMeter{}))
+	require.NoError(t, err)
+
+	data, err := recording.Marshal()
+	require.NoError(t, err)
+	recording, err = hostenv.ParseRecording(data)
+	require.NoError(t, err)
+	replay := hostenv.NewReplayHostEnv(recording)
+	replayed, err := recording.Run(recording.NewGoAPI(replay, &lib.GasMeter{}))
+	require.NoError(t, err)
+	require.NoError(t, replay.Verify())
+	require.NotZero(t, recorded.GasUsed)
+	require.Equal(t, recorded.GasUsed, replayed.GasUsed)
+	require.Equal(t, recorded.RemainingGas, replayed.RemainingGas)
+}
+
+func TestRecordingRules(t *testing.T) {
+	forks := &lib.ForkConfig{Forks: []lib.Fork{{Name: "call", Height: 10, Enable: []lib.HostFunction{lib.HostCall}}}}
+	recording := &hostenv.Recording{GasSchedule: lib.DefaultGasSchedule(), Forks: forks}
+	data, err := recording.Marshal()
+	require.NoError(t, err)
+	parsed, err := hostenv.ParseRecording(data)
+	require.NoError(t, err)
+	require.Equal(t, recording.GasSchedule, parsed.GasSchedule)
+	require.Equal(t, recording.Forks, parsed.Forks)
+
+	api := parsed.NewGoAPI(hostenv.NewReplayHostEnv(parsed), &lib.GasMeter{})
+	require.Equal(t, parsed.GasSchedule, api.GasSchedule())
+}
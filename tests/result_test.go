@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	models "github.com/idena-network/idena-wasm-binding/lib/protobuf"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestDecodeExecutionResult(t *testing.T) {
+	root := &models.ActionResult{
+		InputAction: &models.Action{
+			ActionType: lib.ActionFunctionCall,
+			Amount:     big.NewInt(100).Bytes(),
+			Method:     "transfer",
+			Args:       lib.PackArguments([][]byte{{0x1}, nil}),
+			GasLimit:   1000,
+		},
+		Success:  false,
+		Error:    "sub call failed",
+		GasUsed:  500,
+		Contract: []byte{0x1},
+		SubActionResults: []*models.ActionResult{
+			{
+				InputAction: &models.Action{ActionType: lib.ActionReadIdentity},
+				Success:     true,
+				Contract:    []byte{0x2},
+			},
+			{
+				InputAction: &models.Action{ActionType: lib.ActionDeployContract, Method: "deploy"},
+				Success:     false,
+				Error:       "contract is already deployed",
+				Contract:    []byte{0x3},
+			},
+		},
+	}
+	data, err := proto.Marshal(root)
+	require.NoError(t, err)
+
+	result, err := lib.DecodeExecutionResult(data)
+	require.NoError(t, err)
+	require.Equal(t, data, result.Raw)
+	require.Equal(t, lib.Address{0x1}, result.Contract)
+	require.Equal(t, lib.ActionType(lib.ActionFunctionCall), result.Action.Type)
+	require.Equal(t, "call", result.Action.Type.String())
+	require.Equal(t, big.NewInt(100), result.Action.Amount)
+	require.Equal(t, [][]byte{{0x1}, nil}, result.Action.Arguments())
+
+	nodes := result.Flatten()
+	require.Len(t, nodes, 3)
+	require.Equal(t, lib.Address{0x2}, nodes[1].Contract)
+	require.Nil(t, nodes[1].Raw)
+
+	failed := result.FailedNode()
+	require.NotNil(t, failed)
+	require.Equal(t, lib.Address{0x3}, failed.Contract)
+	require.Equal(t, "contract is already deployed", failed.Error)
+	require.Equal(t, []int{1}, result.FailedPath())
+
+	var depths []int
+	result.Walk(func(node *lib.ExecutionResult, depth int) bool {
+		depths = append(depths, depth)
+		return true
+	})
+	require.Equal(t, []int{0, 1, 1}, depths)
+
+	_, err = lib.DecodeExecutionResult([]byte{0xff, 0xff})
+	require.Error(t, err)
+}
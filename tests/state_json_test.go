@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestStateJSON(t *testing.T) {
+	contract, addr := lib.Address{0x1}, lib.Address{0x2}
+	code := []byte{0x0, 0x61, 0x73, 0x6d}
+	state := memory.NewState()
+	state.SetStorage(contract, []byte{0x0, 0x1}, []byte("value"))
+	state.SetBalance(addr, big.NewInt(12345))
+	state.SetCode(memory.CodeHash(code), code)
+	state.SetContractCodeHash(contract, memory.CodeHash(code))
+	state.SetIdentity(addr, []byte{0x7})
+
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	restored := memory.NewState()
+	require.NoError(t, json.Unmarshal(data, restored))
+
+	require.Equal(t, []byte("value"), restored.GetStorage(contract, []byte{0x0, 0x1}))
+	require.Equal(t, big.NewInt(12345), restored.Balance(addr))
+	require.Equal(t, code, restored.Code(restored.ContractCodeHash(contract)))
+	require.Equal(t, []byte{0x7}, restored.Identity(addr))
+
+	require.Error(t, json.Unmarshal([]byte(`{"balances":{"01":"1"}}`), restored))
+}
@@ -2,9 +2,15 @@ package testdata
 
 import "embed"
 
-//go:embed sum.wasm
+//go:embed sum.wasm events.wasm
 var content embed.FS
 
 func Sum() ([]byte, error) {
 	return content.ReadFile("sum.wasm")
 }
+
+// Events returns a minimal contract emitting two "emit" events without arguments from its emit method
+// through the emit_event import.
+func Events() ([]byte, error) {
+	return content.ReadFile("events.wasm")
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestTracingHostEnv(t *testing.T) {
+	state := memory.NewState()
+	env := hostenv.NewTracingHostEnv(memory.NewEnv(state, &memory.Block{Number: 5}, memory.Context{Contract: lib.Address{0x1}}), lib.Address{0x1})
+	meter := &lib.GasMeter{}
+	trace := env.Trace()
+
+	// callbacks are reported the way the binding reports them
+	trace.CallbackStarted(lib.HostSetStorage, lib.Address{0x1}, 0)
+	env.SetStorage(meter, []byte{0xa}, []byte{0xb})
+	trace.CallbackFinished(lib.HostSetStorage, 70, nil)
+	require.Equal(t, uint64(5), env.BlockNumber(meter))
+	trace.CallbackStarted(lib.HostCall, lib.Address{0x1}, 0)
+	sub, err := env.CreateSubEnv(lib.Address{0x2}, "inc", big.NewInt(0), false)
+	require.NoError(t, err)
+	trace.CallbackStarted(lib.HostEvent, lib.Address{0x2}, 1)
+	sub.Event(meter, "inc", []byte{0x1})
+	trace.CallbackFinished(lib.HostEvent, 150, nil)
+	require.Error(t, sub.SubBalance(meter, big.NewInt(1)))
+	trace.CallbackFinished(lib.HostCall, 500, lib.ErrContractTrap)
+	_, err = env.CreateSubEnv(lib.Address{0x2}, "inc", big.NewInt(1), false)
+	require.Error(t, err)
+
+	entries := trace.Entries()
+	require.Len(t, entries, 6)
+	require.Equal(t, "cset_storage", entries[0].Callback)
+	require.Equal(t, 0, entries[0].CallbackIndex)
+	require.Equal(t, uint64(20), entries[0].GasUsed)
+	require.Equal(t, 0, entries[0].Depth)
+	require.Empty(t, entries[1].Callback)
+	require.Equal(t, -1, entries[1].CallbackIndex)
+	require.Equal(t, "ccall", entries[2].Callback)
+	require.Equal(t, "cevent", entries[3].Callback)
+	require.Equal(t, 1, entries[3].Depth)
+	require.Equal(t, addressBytes(lib.Address{0x2}), entries[3].Contract)
+	require.Equal(t, "ccall", entries[4].Callback)
+	require.Equal(t, memory.ErrInsufficientBalance.Error(), entries[4].Error)
+	require.Empty(t, entries[5].Callback)
+	require.NotEmpty(t, entries[5].Error)
+
+	callbacks := trace.Callbacks()
+	require.Len(t, callbacks, 3)
+	require.Equal(t, uint64(70), callbacks[0].GasUsed)
+	require.Equal(t, -1, callbacks[1].Parent)
+	require.Equal(t, lib.ErrContractTrap.Error(), callbacks[1].Error)
+	require.Equal(t, "cevent", callbacks[2].Callback)
+	require.Equal(t, 1, callbacks[2].Parent)
+	require.Equal(t, 1, callbacks[2].Depth)
+
+	data, err := trace.JSON()
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"value": "0x0a"`)
+	require.Contains(t, string(data), `"callback": "ccall"`)
+}
+
+func TestTracingCallbackGas(t *testing.T) {
+	code, _ := testdata.Events()
+	env := hostenv.NewTracingHostEnv(memory.NewEnv(memory.NewState(), &memory.Block{}, memory.Context{Contract: lib.Address{0x1}}), lib.Address{0x1})
+	api := lib.NewGoAPIWithGasSchedule(env, &lib.GasMeter{}, lib.DefaultGasSchedule())
+	api.SetCallbackTracer(env.Trace())
+
+	_, _, err := lib.Execute(api, code, "emit", nil, lib.Address{0x1}, 100000, false)
+	require.NoError(t, err)
+
+	// emit calls event with the name "emit" and no args twice
+	callbacks := env.Trace().Callbacks()
+	require.Len(t, callbacks, 2)
+	for _, callback := range callbacks {
+		require.Equal(t, "cevent", callback.Callback)
+		require.Equal(t, uint64(100+10*len("emit")), callback.GasUsed)
+		require.Empty(t, callback.Error)
+	}
+	entries := env.Trace().Entries()
+	require.Len(t, entries, 2)
+	require.Equal(t, "Event", entries[1].Method)
+	require.Equal(t, 1, entries[1].CallbackIndex)
+}
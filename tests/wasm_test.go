@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/idena-network/idena-wasm-binding/wasm"
+	"github.com/stretchr/testify/require"
+	"os"
+	"regexp"
+	"testing"
+)
+
+// wasmSection encodes a section with content shorter than 128 bytes.
+func wasmSection(id wasm.SectionID, content ...byte) []byte {
+	return append([]byte{byte(id), byte(len(content))}, content...)
+}
+
+func wasmName(name string) []byte {
+	return append([]byte{byte(len(name))}, name...)
+}
+
+func wasmModule(sections ...[]byte) []byte {
+	res := append(append([]byte{}, wasm.Magic...), wasm.Version...)
+	for _, s := range sections {
+		res = append(res, s...)
+	}
+	return res
+}
+
+func concat(parts ...[]byte) []byte {
+	var res []byte
+	for _, p := range parts {
+		res = append(res, p...)
+	}
+	return res
+}
+
+// testContract returns a module importing (i32, i32) -> () host functions from env with exports deploy,
+// allocate and memory, the first defined function is exported as deploy and allocate.
+// Extra sections are inserted after the memory section, data sections after the code section.
+func testContract(imports []string, bodies [][]byte, extra ...[]byte) []byte {
+	importSection := []byte{byte(len(imports))}
+	for _, name := range imports {
+		importSection = append(importSection, concat(wasmName("env"), wasmName(name), []byte{0, 1})...)
+	}
+	functions := []byte{byte(len(bodies))}
+	code := []byte{byte(len(bodies))}
+	for _, body := range bodies {
+		functions = append(functions, 0)
+		code = append(code, byte(len(body)))
+		code = append(code, body...)
+	}
+	first := byte(len(imports))
+	sections := [][]byte{
+		// () -> () and (i32, i32) -> ()
+		wasmSection(wasm.SectionType, 2, 0x60, 0, 0, 0x60, 2, 0x7f, 0x7f, 0),
+		wasmSection(wasm.SectionImport, importSection...),
+		wasmSection(wasm.SectionFunction, functions...),
+		wasmSection(wasm.SectionMemory, 1, 0, 1),
+	}
+	var data [][]byte
+	for _, section := range extra {
+		if wasm.SectionID(section[0]) == wasm.SectionData {
+			data = append(data, section)
+		} else {
+			sections = append(sections, section)
+		}
+	}
+	sections = append(sections,
+		wasmSection(wasm.SectionExport, concat([]byte{3},
+			wasmName("deploy"), []byte{0, first},
+			wasmName("allocate"), []byte{0, first},
+			wasmName("memory"), []byte{2, 0})...),
+		wasmSection(wasm.SectionCode, code...),
+	)
+	return wasmModule(append(sections, data...)...)
+}
+
+func TestValidate(t *testing.T) {
+	emptyBody := []byte{0, 0x0b}
+	report := wasm.Validate(testContract([]string{"set_storage"}, [][]byte{emptyBody}), nil)
+	require.True(t, report.Valid(), report.Err())
+	require.Len(t, report.Module.Codes, 1)
+
+	report = wasm.Validate([]byte{0x0, 0x61, 0x73, 0x6d, 0x2, 0x0, 0x0, 0x0}, nil)
+	require.False(t, report.Valid())
+	require.Equal(t, wasm.IssueMalformed, report.Issues[0].Kind)
+	require.Nil(t, report.Module)
+
+	// f32.const 1.0, drop
+	floatBody := []byte{0, 0x43, 0, 0, 0x80, 0x3f, 0x1a, 0x0b}
+	// a wasi import, a memory import of 1000 pages, a start function and no contract exports
+	code := wasmModule(
+		wasmSection(wasm.SectionType, 1, 0x60, 0, 0),
+		wasmSection(wasm.SectionImport, concat([]byte{2},
+			wasmName("env"), wasmName("memory"), []byte{2, 0, 0xe8, 0x07},
+			wasmName("wasi"), wasmName("fd_write"), []byte{0, 0})...),
+		wasmSection(wasm.SectionFunction, 1, 0),
+		wasmSection(wasm.SectionExport, concat([]byte{1}, wasmName("deploy"), []byte{0, 1})...),
+		wasmSection(wasm.SectionStart, 1),
+		wasmSection(wasm.SectionCode, append([]byte{1, byte(len(floatBody))}, floatBody...)...),
+	)
+	report = wasm.Validate(code, nil)
+	kinds := map[wasm.IssueKind]int{}
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+	}
+	require.Equal(t, map[wasm.IssueKind]int{
+		wasm.IssueForbiddenImport: 2,
+		wasm.IssueMissingExport:   2,
+		wasm.IssueMemoryTooLarge:  1,
+		wasm.IssueStartFunction:   1,
+		wasm.IssueFloat:           1,
+	}, kinds)
+	require.Error(t, report.Err())
+
+	config := wasm.DefaultValidationConfig()
+	config.AllowFloats = true
+	config.MaxMemoryPages = 1000
+	for _, issue := range wasm.Validate(code, config).Issues {
+		require.NotEqual(t, wasm.IssueFloat, issue.Kind)
+		require.NotEqual(t, wasm.IssueMemoryTooLarge, issue.Kind)
+	}
+}
+
+func TestValidateSum(t *testing.T) {
+	code, err := testdata.Sum()
+	require.NoError(t, err)
+	report := wasm.Validate(code, nil)
+	require.NotNil(t, report.Module)
+	// the contract is built with AssemblyScript which initializes globals in a start function
+	require.Len(t, report.Issues, 1)
+	require.Equal(t, wasm.IssueStartFunction, report.Issues[0].Kind)
+
+	config := wasm.DefaultValidationConfig()
+	config.AllowStartFunction = true
+	report = wasm.Validate(code, config)
+	require.True(t, report.Valid(), "%v", report.Err())
+}
+
+func TestValidateEvents(t *testing.T) {
+	code, err := testdata.Events()
+	require.NoError(t, err)
+	report := wasm.Validate(code, nil)
+	require.True(t, report.Valid(), "%v", report.Err())
+}
+
+// TestVMImportsBackedByVtable checks that the VM import table is served by callbacks of the GoApi vtable
+// and covers the imports of the sample contract.
+func TestVMImportsBackedByVtable(t *testing.T) {
+	header, err := os.ReadFile("../lib/bindings.h")
+	require.NoError(t, err)
+	vtable := regexp.MustCompile(`(?s)typedef struct GoApi_vtable \{(.*?)\} GoApi_vtable;`).FindSubmatch(header)
+	require.NotNil(t, vtable)
+	callbacks := map[lib.HostFunction]bool{}
+	for _, m := range regexp.MustCompile(`\(\*(\w+)\)`).FindAllSubmatch(vtable[1], -1) {
+		callbacks[lib.HostFunction(m[1])] = true
+	}
+	for _, imp := range wasm.VMImports {
+		if imp.Host != "" {
+			require.True(t, callbacks[imp.Host], "%v is served by unknown callback %v", imp.Name, imp.Host)
+		}
+	}
+
+	code, err := testdata.Sum()
+	require.NoError(t, err)
+	m, err := wasm.Parse(code)
+	require.NoError(t, err)
+	for _, imp := range m.Imports {
+		_, ok := wasm.VMImportHost(imp.Name)
+		require.True(t, ok, "%v is not in the VM import table", imp.Name)
+	}
+	fn, _ := wasm.VMImportHost("create_call_function_promise")
+	require.Equal(t, lib.HostCall, fn)
+	_, ok := wasm.VMImportHost(string(lib.HostDeductBalance))
+	require.False(t, ok)
+}
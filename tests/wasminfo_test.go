@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"encoding/hex"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/idena-network/idena-wasm-binding/wasm"
+	"github.com/idena-network/idena-wasm-binding/wasminfo"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	body := []byte{0, 0x0b}
+	code := testContract([]string{"set_storage"}, [][]byte{body, body},
+		wasmSection(wasm.SectionCustom, concat(wasmName("name"),
+			// function names subsection naming function 2
+			[]byte{1, 10, 1, 2}, wasmName("helper!"))...))
+	info, err := wasminfo.Inspect(code)
+	require.NoError(t, err)
+	require.Equal(t, "0x"+hex.EncodeToString(memory.CodeHash(code)), info.CodeHash)
+	require.Equal(t, len(code), info.Size)
+	require.Equal(t, []string{"set_storage"}, info.HostFunctions())
+	require.Equal(t, "(i32, i32)", info.Imports[0].Signature.String())
+	require.Equal(t, []wasminfo.Memory{{Min: 1}}, info.Memories)
+	require.Equal(t, []wasminfo.CustomSection{{Name: "name", Size: 12}}, info.CustomSections)
+	require.Equal(t, []wasminfo.Function{
+		{Index: 1, Name: "deploy", Signature: &wasminfo.Signature{Params: []string{}, Results: []string{}}, CodeSize: 2},
+		{Index: 2, Name: "helper!", Signature: &wasminfo.Signature{Params: []string{}, Results: []string{}}, CodeSize: 2},
+	}, info.Functions)
+	require.Empty(t, info.Methods())
+
+	sum, err := testdata.Sum()
+	require.NoError(t, err)
+	info, err = wasminfo.Inspect(sum)
+	require.NoError(t, err)
+	require.Equal(t, []string{"compute", "read_sub_result_promise", "read_sub_result_promise_callback",
+		"sub_compute_promise", "sub_compute_promise_callback"}, info.Methods())
+
+	_, err = wasminfo.Inspect([]byte("not wasm"))
+	require.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/idena-network/idena-wasm-binding/tests/testdata"
+	"github.com/idena-network/idena-wasm-binding/wasmtest"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestChainSum(t *testing.T) {
+	code, _ := testdata.Sum()
+	chain := wasmtest.NewChain(t)
+
+	contract := chain.Deploy(code, ToBytes(uint64(1))).RequireSuccess().Contract
+	chain.NextBlock()
+	chain.Call(contract, "compute", ToBytes(uint64(10))).RequireSuccess()
+
+	estimate, err := chain.From(wasmtest.DefaultCaller).EstimateCall(contract, "compute", ToBytes(uint64(10)))
+	require.NoError(t, err)
+	chain.From(wasmtest.DefaultCaller).WithGasLimit(estimate.GasLimit).Call(contract, "compute", ToBytes(uint64(10))).RequireSuccess()
+}
+
+func TestChainFixture(t *testing.T) {
+	chain := wasmtest.NewChain(t)
+	caller := lib.Address{0x5}
+	chain.SetBalance(caller, big.NewInt(100))
+
+	chain.AdvanceBlocks(9)
+	require.Equal(t, uint64(10), chain.Block().Number)
+	chain.NextEpoch()
+	require.Equal(t, uint16(1), chain.Block().Epoch)
+	require.Equal(t, uint64(wasmtest.EpochBlocks), chain.Block().Number)
+
+	chain.From(caller).WithAmount(big.NewInt(10)).Call(lib.Address{0x9}, "transfer").RequireError(lib.ErrCodeEmpty)
+	chain.RequireBalance(caller, big.NewInt(100))
+	chain.RequireNoStorage(lib.Address{0x9}, []byte("key"))
+	_, err := chain.From(caller).EstimateCall(lib.Address{0x9}, "transfer")
+	require.ErrorIs(t, err, lib.ErrCodeEmpty)
+}
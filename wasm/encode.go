@@ -0,0 +1,42 @@
+package wasm
+
+// AppendCustomSection returns a copy of the module with a custom section appended, the module is not validated.
+func AppendCustomSection(code []byte, name string, data []byte) []byte {
+	content := appendU32(nil, uint32(len(name)))
+	content = append(content, name...)
+	content = append(content, data...)
+	res := make([]byte, 0, len(code)+len(content)+6)
+	res = append(res, code...)
+	res = append(res, byte(SectionCustom))
+	res = appendU32(res, uint32(len(content)))
+	return append(res, content...)
+}
+
+// RemoveCustomSections returns a copy of the module without custom sections with the name.
+func RemoveCustomSections(code []byte, name string) ([]byte, error) {
+	m, err := Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]byte, 0, len(code))
+	pos := 0
+	for _, s := range m.Sections {
+		if s.ID != SectionCustom || s.Name != name {
+			continue
+		}
+		res = append(res, code[pos:s.Offset]...)
+		pos = s.Offset + s.Size
+	}
+	return append(res, code[pos:]...), nil
+}
+
+func appendU32(data []byte, v uint32) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(data, b)
+		}
+		data = append(data, b|0x80)
+	}
+}
@@ -0,0 +1,61 @@
+package wasm
+
+import "github.com/idena-network/idena-wasm-binding/lib"
+
+// VMImport is a function the VM links into the env module of contracts. Host is the GoApi vtable callback
+// the VM serves the function with, it is empty for functions the VM implements itself.
+type VMImport struct {
+	Name string
+	Host lib.HostFunction
+}
+
+// VMImports is the import table of the VM, contract imports are resolved by these names rather than by
+// the vtable names. Promises are scheduled by the VM and run through the call, deploy, add_balance,
+// read_contract_data and identity callbacks once the method returns.
+var VMImports = []VMImport{
+	{"abort", ""},
+	{"debug", ""},
+	{"panic", ""},
+	{"set_storage", lib.HostSetStorage},
+	{"get_storage", lib.HostGetStorage},
+	{"remove_storage", lib.HostRemoveStorage},
+	{"block_timestamp", lib.HostBlockTimestamp},
+	{"block_number", lib.HostBlockNumber},
+	{"min_fee_per_gas", lib.HostMinFeePerGas},
+	{"balance", lib.HostBalance},
+	{"block_seed", lib.HostBlockSeed},
+	{"network_size", lib.HostNetworkSize},
+	{"identity", lib.HostIdentity},
+	{"caller", lib.HostCaller},
+	{"original_caller", lib.HostOriginalCaller},
+	{"own_addr", lib.HostContract},
+	{"own_code", lib.HostOwnCode},
+	{"code_hash", lib.HostCodeHash},
+	{"contract_addr", lib.HostContractAddr},
+	{"contract_addr_by_hash", lib.HostContractAddrByHash},
+	{"epoch", lib.HostEpoch},
+	{"pay_amount", lib.HostPayAmount},
+	{"emit_event", lib.HostEvent},
+	{"block_header", lib.HostBlockHeader},
+	{"keccak256", lib.HostKeccak256},
+	{"global_state", lib.HostGlobalState},
+	{"burn", lib.HostBurn},
+	{"ecrecover", lib.HostEcrecover},
+	{"create_call_function_promise", lib.HostCall},
+	{"create_deploy_contract_promise", lib.HostDeploy},
+	{"create_transfer_promise", lib.HostAddBalance},
+	{"create_read_contract_data_promise", lib.HostReadContractData},
+	{"create_get_identity_promise", lib.HostIdentity},
+	{"promise_then", ""},
+	{"promise_result", ""},
+}
+
+// VMImportHost returns the host function backing the VM import, ok is false if the VM does not provide the import.
+func VMImportHost(name string) (fn lib.HostFunction, ok bool) {
+	for _, imp := range VMImports {
+		if imp.Name == name {
+			return imp.Host, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,177 @@
+package wasm
+
+const (
+	OpUnreachable  byte = 0x00
+	OpBlock        byte = 0x02
+	OpLoop         byte = 0x03
+	OpIf           byte = 0x04
+	OpEnd          byte = 0x0b
+	OpBr           byte = 0x0c
+	OpBrIf         byte = 0x0d
+	OpBrTable      byte = 0x0e
+	OpCall         byte = 0x10
+	OpCallIndirect byte = 0x11
+	OpSelectTyped  byte = 0x1c
+	OpMemorySize   byte = 0x3f
+	OpMemoryGrow   byte = 0x40
+	OpI32Const     byte = 0x41
+	OpI64Const     byte = 0x42
+	OpF32Const     byte = 0x43
+	OpF64Const     byte = 0x44
+	OpGlobalGet    byte = 0x23
+	OpRefFunc      byte = 0xd2
+	// OpMisc prefixes saturating truncations and bulk memory instructions
+	OpMisc byte = 0xfc
+	// OpSIMD prefixes vector instructions
+	OpSIMD byte = 0xfd
+)
+
+// Instruction is a decoded instruction, Offset is its position in the module.
+type Instruction struct {
+	Opcode byte
+	// Misc is the sub opcode of OpMisc instructions
+	Misc   uint32
+	Offset int
+	// Index is the first index immediate, e.g. the callee of call or the type of call_indirect
+	Index uint32
+	// Const is the value of i32.const and i64.const
+	Const int64
+}
+
+// IsFloat reports whether the instruction operates on floating point values.
+func (i Instruction) IsFloat() bool {
+	switch {
+	case i.Opcode == OpMisc:
+		// saturating truncations
+		return i.Misc <= 7
+	case i.Opcode == 0x2a || i.Opcode == 0x2b || i.Opcode == 0x38 || i.Opcode == 0x39:
+		// loads and stores
+		return true
+	case i.Opcode == OpF32Const || i.Opcode == OpF64Const:
+		return true
+	case i.Opcode >= 0x5b && i.Opcode <= 0x66:
+		// comparisons
+		return true
+	case i.Opcode >= 0x8b && i.Opcode <= 0xa6:
+		// arithmetic
+		return true
+	case i.Opcode >= 0xa8 && i.Opcode <= 0xbf:
+		// conversions, i64.extend_i32 takes no float
+		return i.Opcode != 0xac && i.Opcode != 0xad
+	default:
+		return false
+	}
+}
+
+// Instructions decodes a function body expression or a constant expression.
+func Instructions(expr []byte, offset int) ([]Instruction, error) {
+	r := newReader(expr, offset)
+	var res []Instruction
+	for !r.eof() {
+		ins, err := readInstruction(r)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ins)
+	}
+	return res, nil
+}
+
+// readConstExpr reads a constant expression up to and including its end.
+func readConstExpr(r *reader) ([]byte, error) {
+	start := r.pos
+	for {
+		ins, err := readInstruction(r)
+		if err != nil {
+			return nil, err
+		}
+		if ins.Opcode == OpEnd {
+			return r.data[start:r.pos], nil
+		}
+	}
+}
+
+func readInstruction(r *reader) (Instruction, error) {
+	ins := Instruction{Offset: r.at()}
+	op, err := r.byte()
+	if err != nil {
+		return ins, err
+	}
+	ins.Opcode = op
+	switch {
+	case op == OpBlock || op == OpLoop || op == OpIf:
+		err = readBlockType(r)
+	case op == OpBr || op == OpBrIf || op == OpCall || op == OpRefFunc || (op >= 0x20 && op <= 0x26):
+		ins.Index, err = r.u32()
+	case op == OpBrTable:
+		var n int
+		if n, err = r.count(); err == nil {
+			for i := 0; i <= n && err == nil; i++ {
+				_, err = r.u32()
+			}
+		}
+	case op == OpCallIndirect:
+		if ins.Index, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case op == OpSelectTyped:
+		_, err = valueTypes(r)
+	case op >= 0x28 && op <= 0x3e:
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case op == OpMemorySize || op == OpMemoryGrow:
+		_, err = r.u32()
+	case op == OpI32Const:
+		ins.Const, err = r.sleb(32)
+	case op == OpI64Const:
+		ins.Const, err = r.sleb(64)
+	case op == OpF32Const:
+		_, err = r.bytes(4)
+	case op == OpF64Const:
+		_, err = r.bytes(8)
+	case op == 0xd0:
+		_, err = r.byte()
+	case op == OpMisc:
+		err = readMisc(r, &ins)
+	case op == 0x00 || op == 0x01 || op == 0x05 || op == OpEnd || op == 0x0f || op == 0x1a || op == 0x1b ||
+		(op >= 0x45 && op <= 0xc4) || op == 0xd1:
+	default:
+		return ins, r.errorf("unsupported opcode %#x", op)
+	}
+	return ins, err
+}
+
+func readBlockType(r *reader) error {
+	if r.eof() {
+		return r.errorf("%v", errUnexpectedEnd)
+	}
+	if b := r.data[r.pos]; b == 0x40 || ValueType(b).isValid() {
+		r.pos++
+		return nil
+	}
+	_, err := r.sleb(33)
+	return err
+}
+
+func readMisc(r *reader, ins *Instruction) error {
+	var err error
+	if ins.Misc, err = r.u32(); err != nil {
+		return err
+	}
+	switch ins.Misc {
+	case 0, 1, 2, 3, 4, 5, 6, 7:
+		return nil
+	case 9, 13, 15, 16, 17:
+		ins.Index, err = r.u32()
+	case 8, 10, 12, 14:
+		if ins.Index, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case 11:
+		_, err = r.u32()
+	default:
+		return r.errorf("unsupported opcode 0xfc %v", ins.Misc)
+	}
+	return err
+}
@@ -0,0 +1,224 @@
+package wasm
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"sort"
+)
+
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether the severity is not lower than other.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+type Rule string
+
+const (
+	RuleFloat           Rule = "float"
+	RuleMemoryGrowLoop  Rule = "memory-grow-in-loop"
+	RuleSelfCall        Rule = "self-call"
+	RuleLargeData       Rule = "large-data"
+	RuleUnsupportedCode Rule = "unsupported-code"
+)
+
+// Finding is a lint finding, Function is the function index or -1 if the finding is not specific to a function.
+type Finding struct {
+	Rule     Rule
+	Severity Severity
+	Message  string
+	Function int
+	Offset   int
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%v [%v] at %#x: %v", f.Severity, f.Rule, f.Offset, f.Message)
+}
+
+type LintReport struct {
+	Findings []Finding
+}
+
+func (r *LintReport) add(rule Rule, severity Severity, offset int, function int, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Function: function,
+		Offset:   offset,
+	})
+}
+
+// Filter returns findings with severity not lower than min.
+func (r *LintReport) Filter(min Severity) []Finding {
+	var res []Finding
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(min) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+type LintConfig struct {
+	// MaxDataSegment is the size of a data segment in bytes considered large
+	MaxDataSegment int
+	// MaxData is the total size of data segments in bytes considered large
+	MaxData int
+}
+
+func DefaultLintConfig() *LintConfig {
+	return &LintConfig{
+		MaxDataSegment: 64 * 1024,
+		MaxData:        256 * 1024,
+	}
+}
+
+// Lint looks for code which risks consensus divergence between node versions: floating point arithmetic,
+// memory growth in loops, contracts calling themselves through the call host function and large data segments.
+// Unlike Validate it reports risks rather than rejects code. A nil config means DefaultLintConfig.
+func Lint(code []byte, config *LintConfig) (*LintReport, error) {
+	if config == nil {
+		config = DefaultLintConfig()
+	}
+	m, err := Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	report := &LintReport{}
+	lintData(report, m, config)
+
+	imported := m.ImportedFunctions()
+	// callees holds defined functions called directly, hostCalls holds host functions backing VM imports called directly
+	callees := make([][]uint32, len(m.Codes))
+	hostCalls := make([]map[lib.HostFunction]bool, len(m.Codes))
+	for i, c := range m.Codes {
+		function := imported + i
+		instructions, err := Instructions(c.Expr, c.ExprOffset)
+		if err != nil {
+			report.add(RuleUnsupportedCode, SeverityError, c.Offset, function, "function %v cannot be decoded: %v", function, err)
+			continue
+		}
+		hostCalls[i] = map[lib.HostFunction]bool{}
+		lintFunction(report, m, function, c, instructions)
+		for _, ins := range instructions {
+			if ins.Opcode != OpCall {
+				continue
+			}
+			if imp := m.FunctionImport(ins.Index); imp != nil {
+				if fn, ok := VMImportHost(imp.Name); ok && imp.Module == HostModule && fn != "" {
+					hostCalls[i][fn] = true
+				}
+			} else if int(ins.Index) >= imported {
+				callees[i] = append(callees[i], ins.Index-uint32(imported))
+			}
+		}
+	}
+	lintSelfCalls(report, m, callees, hostCalls)
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Offset < report.Findings[j].Offset
+	})
+	return report, nil
+}
+
+func lintData(report *LintReport, m *Module, config *LintConfig) {
+	offset := sectionOffset(m, SectionData)
+	total := 0
+	for i, segment := range m.Data {
+		total += len(segment.Data)
+		if len(segment.Data) > config.MaxDataSegment {
+			report.add(RuleLargeData, SeverityWarning, offset, -1, "data segment %v has %v bytes", i, len(segment.Data))
+		}
+	}
+	if total > config.MaxData {
+		report.add(RuleLargeData, SeverityWarning, offset, -1, "data segments have %v bytes in total", total)
+	}
+}
+
+func lintFunction(report *LintReport, m *Module, function int, c Code, instructions []Instruction) {
+	if t, ok := m.FunctionType(uint32(function)); ok && (hasFloat(t.Params) || hasFloat(t.Results)) {
+		report.add(RuleFloat, SeverityError, c.Offset, function, "function %v has float signature %v", function, t)
+	}
+	for _, local := range c.Locals {
+		if local.Type.IsFloat() {
+			report.add(RuleFloat, SeverityError, c.Offset, function, "function %v has %v locals", function, local.Type)
+			break
+		}
+	}
+	// blocks holds opcodes of enclosing blocks, the final end of the body closes the function itself
+	var blocks []byte
+	loops, floats, firstFloat := 0, 0, 0
+	for _, ins := range instructions {
+		switch {
+		case ins.Opcode == OpBlock || ins.Opcode == OpLoop || ins.Opcode == OpIf:
+			blocks = append(blocks, ins.Opcode)
+			if ins.Opcode == OpLoop {
+				loops++
+			}
+		case ins.Opcode == OpEnd && len(blocks) > 0:
+			if blocks[len(blocks)-1] == OpLoop {
+				loops--
+			}
+			blocks = blocks[:len(blocks)-1]
+		case ins.Opcode == OpMemoryGrow && loops > 0:
+			report.add(RuleMemoryGrowLoop, SeverityWarning, ins.Offset, function,
+				"function %v grows memory in a loop, memory limits and growth costs differ between VM versions", function)
+		case ins.IsFloat():
+			if floats == 0 {
+				firstFloat = ins.Offset
+			}
+			floats++
+		}
+	}
+	if floats > 0 {
+		report.add(RuleFloat, SeverityError, firstFloat, function, "function %v has %v float instructions", function, floats)
+	}
+}
+
+// lintSelfCalls reports exported functions which both read the own contract address and create a call promise,
+// such functions may call the contract itself recursively until the call depth limit.
+func lintSelfCalls(report *LintReport, m *Module, callees [][]uint32, hostCalls []map[lib.HostFunction]bool) {
+	imported := uint32(m.ImportedFunctions())
+	for _, e := range m.Exports {
+		if e.Kind != ExternalFunction || e.Index < imported {
+			continue
+		}
+		reached := map[lib.HostFunction]bool{}
+		visited := make([]bool, len(callees))
+		stack := []uint32{e.Index - imported}
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if int(i) >= len(visited) || visited[i] {
+				continue
+			}
+			visited[i] = true
+			for name := range hostCalls[i] {
+				reached[name] = true
+			}
+			stack = append(stack, callees[i]...)
+		}
+		if reached[lib.HostContract] && reached[lib.HostCall] {
+			report.add(RuleSelfCall, SeverityWarning, m.Codes[e.Index-imported].Offset, int(e.Index),
+				"method %v may call its own contract, recursion is bounded by the call depth limit only", e.Name)
+		}
+	}
+}
@@ -0,0 +1,639 @@
+// Package wasm parses Wasm modules in the binary format, it is enough to inspect and validate contract code
+// without instantiating it.
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// maxLocals is the limit of locals per function used by Wasm engines.
+const maxLocals = 50000
+
+// HostModule is the module contracts import host functions from.
+const HostModule = "env"
+
+var (
+	Magic   = []byte{0x00, 0x61, 0x73, 0x6d}
+	Version = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+type ValueType byte
+
+const (
+	I32       ValueType = 0x7f
+	I64       ValueType = 0x7e
+	F32       ValueType = 0x7d
+	F64       ValueType = 0x7c
+	V128      ValueType = 0x7b
+	FuncRef   ValueType = 0x70
+	ExternRef ValueType = 0x6f
+)
+
+func (t ValueType) isValid() bool {
+	switch t {
+	case I32, I64, F32, F64, V128, FuncRef, ExternRef:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t ValueType) IsFloat() bool {
+	return t == F32 || t == F64
+}
+
+func (t ValueType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	case F32:
+		return "f32"
+	case F64:
+		return "f64"
+	case V128:
+		return "v128"
+	case FuncRef:
+		return "funcref"
+	case ExternRef:
+		return "externref"
+	default:
+		return fmt.Sprintf("type(%#x)", byte(t))
+	}
+}
+
+type SectionID byte
+
+const (
+	SectionCustom SectionID = iota
+	SectionType
+	SectionImport
+	SectionFunction
+	SectionTable
+	SectionMemory
+	SectionGlobal
+	SectionExport
+	SectionStart
+	SectionElement
+	SectionCode
+	SectionData
+	SectionDataCount
+)
+
+// sectionOrder is the position of known sections, data count goes between element and code.
+var sectionOrder = map[SectionID]int{
+	SectionType: 1, SectionImport: 2, SectionFunction: 3, SectionTable: 4, SectionMemory: 5, SectionGlobal: 6,
+	SectionExport: 7, SectionStart: 8, SectionElement: 9, SectionDataCount: 10, SectionCode: 11, SectionData: 12,
+}
+
+type ExternalKind byte
+
+const (
+	ExternalFunction ExternalKind = iota
+	ExternalTable
+	ExternalMemory
+	ExternalGlobal
+)
+
+func (k ExternalKind) String() string {
+	switch k {
+	case ExternalFunction:
+		return "func"
+	case ExternalTable:
+		return "table"
+	case ExternalMemory:
+		return "memory"
+	case ExternalGlobal:
+		return "global"
+	default:
+		return fmt.Sprintf("kind(%v)", byte(k))
+	}
+}
+
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+func (t FuncType) String() string {
+	return fmt.Sprintf("func%v -> %v", t.Params, t.Results)
+}
+
+// Limits of a memory in pages or of a table in elements, Max is nil if not declared.
+type Limits struct {
+	Min uint32
+	Max *uint32
+}
+
+type Import struct {
+	Module string
+	Name   string
+	Kind   ExternalKind
+	// Type is the type index of an imported function
+	Type   uint32
+	Limits Limits
+	Global GlobalType
+}
+
+type GlobalType struct {
+	Type    ValueType
+	Mutable bool
+}
+
+type Global struct {
+	GlobalType
+	Init []byte
+}
+
+type Table struct {
+	Type   ValueType
+	Limits Limits
+}
+
+type Export struct {
+	Name  string
+	Kind  ExternalKind
+	Index uint32
+}
+
+type Local struct {
+	Count uint32
+	Type  ValueType
+}
+
+// Code is a function body, Offset and Size locate the body including locals in the module.
+type Code struct {
+	Offset int
+	Size   int
+	Locals []Local
+	// Expr holds the instructions of the body
+	Expr []byte
+	// ExprOffset is the position of Expr in the module
+	ExprOffset int
+}
+
+type DataSegment struct {
+	Passive bool
+	Memory  uint32
+	// OffsetExpr is the constant expression of an active segment offset
+	OffsetExpr []byte
+	Data       []byte
+}
+
+type CustomSection struct {
+	Name string
+	Data []byte
+}
+
+// Section is a raw section, Offset is the position of the section id and Size includes the header.
+type Section struct {
+	ID     SectionID
+	Offset int
+	Size   int
+	// Name is the name of a custom section
+	Name string
+}
+
+type Module struct {
+	Sections       []Section
+	Types          []FuncType
+	Imports        []Import
+	Functions      []uint32
+	Tables         []Table
+	Memories       []Limits
+	Globals        []Global
+	Exports        []Export
+	Start          *uint32
+	Codes          []Code
+	Data           []DataSegment
+	CustomSections []CustomSection
+	// Elements holds the raw element section content
+	Elements []byte
+}
+
+// ImportedFunctions returns the number of imported functions, they precede defined functions in the index space.
+func (m *Module) ImportedFunctions() int {
+	n := 0
+	for _, imp := range m.Imports {
+		if imp.Kind == ExternalFunction {
+			n++
+		}
+	}
+	return n
+}
+
+// FunctionImport returns the import of the function index or nil if the function is defined by the module.
+func (m *Module) FunctionImport(index uint32) *Import {
+	n := uint32(0)
+	for i := range m.Imports {
+		if m.Imports[i].Kind != ExternalFunction {
+			continue
+		}
+		if n == index {
+			return &m.Imports[i]
+		}
+		n++
+	}
+	return nil
+}
+
+// FunctionType returns the signature of the function index, imported functions included.
+func (m *Module) FunctionType(index uint32) (FuncType, bool) {
+	var typeIndex uint32
+	if imp := m.FunctionImport(index); imp != nil {
+		typeIndex = imp.Type
+	} else {
+		defined := int(index) - m.ImportedFunctions()
+		if defined < 0 || defined >= len(m.Functions) {
+			return FuncType{}, false
+		}
+		typeIndex = m.Functions[defined]
+	}
+	if int(typeIndex) >= len(m.Types) {
+		return FuncType{}, false
+	}
+	return m.Types[typeIndex], true
+}
+
+func (m *Module) Export(name string) *Export {
+	for i := range m.Exports {
+		if m.Exports[i].Name == name {
+			return &m.Exports[i]
+		}
+	}
+	return nil
+}
+
+// CustomSection returns the data of the first custom section with the name.
+func (m *Module) CustomSection(name string) ([]byte, bool) {
+	for _, s := range m.CustomSections {
+		if s.Name == name {
+			return s.Data, true
+		}
+	}
+	return nil, false
+}
+
+// Parse decodes the module structure, function bodies are split but their instructions are not decoded.
+func Parse(code []byte) (*Module, error) {
+	if len(code) < 8 || !bytes.Equal(code[:4], Magic) {
+		return nil, fmt.Errorf("bad magic number")
+	}
+	if !bytes.Equal(code[4:8], Version) {
+		return nil, fmt.Errorf("unsupported version %x", code[4:8])
+	}
+	m := &Module{}
+	r := newReader(code, 0)
+	r.pos = 8
+	last := 0
+	functionsDeclared := false
+	for !r.eof() {
+		start := r.pos
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		bodyOffset := r.pos
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return nil, fmt.Errorf("section %v: %w", id, err)
+		}
+		sectionID := SectionID(id)
+		if sectionID != SectionCustom {
+			order, ok := sectionOrder[sectionID]
+			if !ok {
+				return nil, fmt.Errorf("offset %#x: unknown section %v", start, id)
+			}
+			if order <= last {
+				return nil, fmt.Errorf("offset %#x: section %v is out of order", start, id)
+			}
+			last = order
+		}
+		m.Sections = append(m.Sections, Section{ID: sectionID, Offset: start, Size: r.pos - start})
+		if sectionID == SectionFunction {
+			functionsDeclared = true
+		}
+		sr := newReader(body, bodyOffset)
+		if err := m.parseSection(sectionID, sr); err != nil {
+			return nil, err
+		}
+		if sectionID != SectionCustom && !sr.eof() {
+			return nil, sr.errorf("section %v has trailing data", id)
+		}
+	}
+	if len(m.Codes) != len(m.Functions) || (len(m.Codes) > 0 && !functionsDeclared) {
+		return nil, fmt.Errorf("function count %v does not match code count %v", len(m.Functions), len(m.Codes))
+	}
+	return m, nil
+}
+
+func (m *Module) parseSection(id SectionID, r *reader) error {
+	switch id {
+	case SectionCustom:
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		m.CustomSections = append(m.CustomSections, CustomSection{Name: name, Data: r.data[r.pos:]})
+		m.Sections[len(m.Sections)-1].Name = name
+		return nil
+	case SectionElement:
+		m.Elements = r.data
+		r.pos = len(r.data)
+		return nil
+	case SectionStart:
+		index, err := r.u32()
+		if err != nil {
+			return err
+		}
+		m.Start = &index
+		return nil
+	case SectionDataCount:
+		_, err := r.u32()
+		return err
+	}
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		var err error
+		switch id {
+		case SectionType:
+			err = m.parseType(r)
+		case SectionImport:
+			err = m.parseImport(r)
+		case SectionFunction:
+			var index uint32
+			if index, err = r.u32(); err == nil {
+				m.Functions = append(m.Functions, index)
+			}
+		case SectionTable:
+			var table Table
+			if table, err = parseTable(r); err == nil {
+				m.Tables = append(m.Tables, table)
+			}
+		case SectionMemory:
+			var limits Limits
+			if limits, err = r.limits(); err == nil {
+				m.Memories = append(m.Memories, limits)
+			}
+		case SectionGlobal:
+			err = m.parseGlobal(r)
+		case SectionExport:
+			err = m.parseExport(r)
+		case SectionCode:
+			err = m.parseCode(r)
+		case SectionData:
+			err = m.parseData(r)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Module) parseType(r *reader) error {
+	form, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if form != 0x60 {
+		return r.errorf("invalid function type form %#x", form)
+	}
+	var t FuncType
+	if t.Params, err = valueTypes(r); err != nil {
+		return err
+	}
+	if t.Results, err = valueTypes(r); err != nil {
+		return err
+	}
+	m.Types = append(m.Types, t)
+	return nil
+}
+
+func valueTypes(r *reader) ([]ValueType, error) {
+	n, err := r.count()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ValueType, 0, n)
+	for i := 0; i < n; i++ {
+		t, err := r.valueType()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+func parseTable(r *reader) (Table, error) {
+	t, err := r.valueType()
+	if err != nil {
+		return Table{}, err
+	}
+	limits, err := r.limits()
+	return Table{Type: t, Limits: limits}, err
+}
+
+func parseGlobalType(r *reader) (GlobalType, error) {
+	t, err := r.valueType()
+	if err != nil {
+		return GlobalType{}, err
+	}
+	mutable, err := r.byte()
+	if err != nil {
+		return GlobalType{}, err
+	}
+	if mutable > 1 {
+		return GlobalType{}, r.errorf("invalid global mutability %#x", mutable)
+	}
+	return GlobalType{Type: t, Mutable: mutable == 1}, nil
+}
+
+func (m *Module) parseImport(r *reader) error {
+	var imp Import
+	var err error
+	if imp.Module, err = r.name(); err != nil {
+		return err
+	}
+	if imp.Name, err = r.name(); err != nil {
+		return err
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return err
+	}
+	imp.Kind = ExternalKind(kind)
+	switch imp.Kind {
+	case ExternalFunction:
+		imp.Type, err = r.u32()
+	case ExternalTable:
+		var table Table
+		table, err = parseTable(r)
+		imp.Limits = table.Limits
+	case ExternalMemory:
+		imp.Limits, err = r.limits()
+	case ExternalGlobal:
+		imp.Global, err = parseGlobalType(r)
+	default:
+		return r.errorf("invalid import kind %#x", kind)
+	}
+	if err != nil {
+		return err
+	}
+	m.Imports = append(m.Imports, imp)
+	return nil
+}
+
+func (m *Module) parseGlobal(r *reader) error {
+	t, err := parseGlobalType(r)
+	if err != nil {
+		return err
+	}
+	init, err := readConstExpr(r)
+	if err != nil {
+		return err
+	}
+	m.Globals = append(m.Globals, Global{GlobalType: t, Init: init})
+	return nil
+}
+
+func (m *Module) parseExport(r *reader) error {
+	name, err := r.name()
+	if err != nil {
+		return err
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if kind > byte(ExternalGlobal) {
+		return r.errorf("invalid export kind %#x", kind)
+	}
+	index, err := r.u32()
+	if err != nil {
+		return err
+	}
+	m.Exports = append(m.Exports, Export{Name: name, Kind: ExternalKind(kind), Index: index})
+	return nil
+}
+
+func (m *Module) parseCode(r *reader) error {
+	size, err := r.count()
+	if err != nil {
+		return err
+	}
+	offset := r.at()
+	data, err := r.bytes(size)
+	if err != nil {
+		return err
+	}
+	body := newReader(data, offset)
+	n, err := body.count()
+	if err != nil {
+		return err
+	}
+	code := Code{Offset: offset, Size: size}
+	var total uint64
+	for i := 0; i < n; i++ {
+		count, err := body.u32()
+		if err != nil {
+			return err
+		}
+		t, err := body.valueType()
+		if err != nil {
+			return err
+		}
+		if total += uint64(count); total > maxLocals {
+			return body.errorf("too many locals")
+		}
+		code.Locals = append(code.Locals, Local{Count: count, Type: t})
+	}
+	code.Expr = data[body.pos:]
+	code.ExprOffset = body.at()
+	m.Codes = append(m.Codes, code)
+	return nil
+}
+
+func (m *Module) parseData(r *reader) error {
+	flags, err := r.u32()
+	if err != nil {
+		return err
+	}
+	var segment DataSegment
+	switch flags {
+	case 0:
+	case 1:
+		segment.Passive = true
+	case 2:
+		if segment.Memory, err = r.u32(); err != nil {
+			return err
+		}
+	default:
+		return r.errorf("invalid data segment flags %v", flags)
+	}
+	if !segment.Passive {
+		if segment.OffsetExpr, err = readConstExpr(r); err != nil {
+			return err
+		}
+	}
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	if segment.Data, err = r.bytes(n); err != nil {
+		return err
+	}
+	m.Data = append(m.Data, segment)
+	return nil
+}
+
+// FunctionNames returns function names from the name custom section, a malformed section is ignored.
+func (m *Module) FunctionNames() map[uint32]string {
+	res := map[uint32]string{}
+	data, ok := m.CustomSection("name")
+	if !ok {
+		return res
+	}
+	r := newReader(data, 0)
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return res
+		}
+		size, err := r.count()
+		if err != nil {
+			return res
+		}
+		sub, err := r.bytes(size)
+		if err != nil || id != 1 {
+			continue
+		}
+		sr := newReader(sub, 0)
+		n, err := sr.count()
+		if err != nil {
+			return res
+		}
+		for i := 0; i < n; i++ {
+			index, err := sr.u32()
+			if err != nil {
+				return res
+			}
+			name, err := sr.name()
+			if err != nil {
+				return res
+			}
+			res[index] = name
+		}
+	}
+	return res
+}
@@ -0,0 +1,159 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var errUnexpectedEnd = errors.New("unexpected end of data")
+
+// reader decodes primitive values of the binary format, offset is the position in the whole module.
+type reader struct {
+	data   []byte
+	pos    int
+	offset int
+}
+
+func newReader(data []byte, offset int) *reader {
+	return &reader{data: data, offset: offset}
+}
+
+func (r *reader) eof() bool {
+	return r.pos >= len(r.data)
+}
+
+// at returns the position in the whole module.
+func (r *reader) at() int {
+	return r.offset + r.pos
+}
+
+func (r *reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %#x: %v", r.at(), fmt.Sprintf(format, args...))
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.eof() {
+		return 0, r.errorf("%v", errUnexpectedEnd)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, r.errorf("%v", errUnexpectedEnd)
+	}
+	res := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return res, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, err := r.uleb(32)
+	return uint32(v), err
+}
+
+func (r *reader) uleb(bits uint) (uint64, error) {
+	var res uint64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits || (bits-shift < 7 && b&0x7f>>(bits-shift) != 0) {
+			return 0, r.errorf("integer is too large")
+		}
+		res |= uint64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return res, nil
+		}
+	}
+}
+
+func (r *reader) sleb(bits uint) (int64, error) {
+	var res int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits {
+			return 0, r.errorf("integer is too large")
+		}
+		res |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				res |= -1 << shift
+			}
+			return res, nil
+		}
+	}
+}
+
+// count reads a vector length, every element takes at least a byte so larger lengths are malformed.
+func (r *reader) count() (int, error) {
+	n, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(n) > len(r.data)-r.pos {
+		return 0, r.errorf("vector length %v exceeds data", n)
+	}
+	return int(n), nil
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.count()
+	if err != nil {
+		return "", err
+	}
+	data, err := r.bytes(n)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(data) {
+		return "", r.errorf("name is not valid UTF-8")
+	}
+	return string(data), nil
+}
+
+func (r *reader) valueType() (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	t := ValueType(b)
+	if !t.isValid() {
+		return 0, r.errorf("invalid value type %#x", b)
+	}
+	return t, nil
+}
+
+func (r *reader) limits() (Limits, error) {
+	flags, err := r.byte()
+	if err != nil {
+		return Limits{}, err
+	}
+	if flags > 3 {
+		return Limits{}, r.errorf("invalid limits flags %#x", flags)
+	}
+	min, err := r.u32()
+	if err != nil {
+		return Limits{}, err
+	}
+	res := Limits{Min: min}
+	if flags&1 != 0 {
+		max, err := r.u32()
+		if err != nil {
+			return Limits{}, err
+		}
+		res.Max = &max
+	}
+	return res, nil
+}
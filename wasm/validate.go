@@ -0,0 +1,242 @@
+package wasm
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"strings"
+)
+
+type IssueKind string
+
+const (
+	// IssueMalformed is a module which cannot be decoded, including a bad magic number or version
+	IssueMalformed       IssueKind = "malformed"
+	IssueForbiddenImport IssueKind = "forbidden_import"
+	IssueMissingExport   IssueKind = "missing_export"
+	IssueMemoryTooLarge  IssueKind = "memory_too_large"
+	IssueStartFunction   IssueKind = "start_function"
+	// IssueFloat is a floating point type or instruction, float results may differ between engines and platforms
+	IssueFloat IssueKind = "float"
+)
+
+// Issue is a validation failure, Function is the function index or -1 if the issue is not specific to a function.
+type Issue struct {
+	Kind     IssueKind
+	Message  string
+	Offset   int
+	Function int
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%v at %#x: %v", i.Kind, i.Offset, i.Message)
+}
+
+type ValidationReport struct {
+	Issues []Issue
+	// Module is nil if the module cannot be parsed
+	Module *Module
+}
+
+func (r *ValidationReport) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *ValidationReport) add(kind IssueKind, offset int, function int, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{
+		Kind:     kind,
+		Message:  fmt.Sprintf(format, args...),
+		Offset:   offset,
+		Function: function,
+	})
+}
+
+// Err returns nil for a valid module and a ValidationError otherwise.
+func (r *ValidationReport) Err() error {
+	if r.Valid() {
+		return nil
+	}
+	return &ValidationError{Issues: r.Issues}
+}
+
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		msgs = append(msgs, issue.String())
+	}
+	return "invalid contract code: " + strings.Join(msgs, "; ")
+}
+
+type ValidationConfig struct {
+	// ImportModule is the only module contracts may import from
+	ImportModule string
+	// Imports are the function names contracts may import
+	Imports map[string]bool
+	// Exports are the names the module must export, "memory" must be a memory and other names functions
+	Exports []string
+	// MaxMemoryPages limits declared minimum and maximum memory sizes in 64 KiB pages
+	MaxMemoryPages uint32
+	AllowFloats    bool
+	// AllowStartFunction accepts modules with a start function, AssemblyScript initializes globals in it
+	AllowStartFunction bool
+}
+
+// DefaultValidationConfig allows imports of the VM import table which the VM implements itself
+// or serves with callbacks of the GoApi vtable.
+func DefaultValidationConfig() *ValidationConfig {
+	vtable := map[lib.HostFunction]bool{}
+	for _, fn := range lib.HostFunctions {
+		vtable[fn] = true
+	}
+	imports := map[string]bool{}
+	for _, imp := range VMImports {
+		if imp.Host == "" || vtable[imp.Host] {
+			imports[imp.Name] = true
+		}
+	}
+	return &ValidationConfig{
+		ImportModule:   HostModule,
+		Imports:        imports,
+		Exports:        []string{"deploy", "allocate", "memory"},
+		MaxMemoryPages: 256,
+	}
+}
+
+// Validate checks contract code before deploy, so hosts can reject bad code without entering the VM.
+// A nil config means DefaultValidationConfig.
+func Validate(code []byte, config *ValidationConfig) *ValidationReport {
+	if config == nil {
+		config = DefaultValidationConfig()
+	}
+	report := &ValidationReport{}
+	m, err := Parse(code)
+	if err != nil {
+		report.add(IssueMalformed, 0, -1, "%v", err)
+		return report
+	}
+	report.Module = m
+	validateImports(report, m, config)
+	validateExports(report, m, config)
+	validateMemories(report, m, config)
+	if m.Start != nil && !config.AllowStartFunction {
+		report.add(IssueStartFunction, sectionOffset(m, SectionStart), -1, "module has start function %v", *m.Start)
+	}
+	validateFunctions(report, m, config)
+	return report
+}
+
+func sectionOffset(m *Module, id SectionID) int {
+	for _, s := range m.Sections {
+		if s.ID == id {
+			return s.Offset
+		}
+	}
+	return 0
+}
+
+func validateImports(report *ValidationReport, m *Module, config *ValidationConfig) {
+	offset := sectionOffset(m, SectionImport)
+	for _, imp := range m.Imports {
+		switch {
+		case imp.Module != config.ImportModule:
+			report.add(IssueForbiddenImport, offset, -1, "import %v.%v from unknown module", imp.Module, imp.Name)
+		case imp.Kind != ExternalFunction:
+			report.add(IssueForbiddenImport, offset, -1, "import %v.%v is a %v, only functions can be imported", imp.Module, imp.Name, imp.Kind)
+		case !config.Imports[imp.Name]:
+			report.add(IssueForbiddenImport, offset, -1, "import %v.%v is not a host function", imp.Module, imp.Name)
+		}
+	}
+}
+
+func validateExports(report *ValidationReport, m *Module, config *ValidationConfig) {
+	offset := sectionOffset(m, SectionExport)
+	for _, name := range config.Exports {
+		kind := ExternalFunction
+		if name == "memory" {
+			kind = ExternalMemory
+		}
+		export := m.Export(name)
+		switch {
+		case export == nil:
+			report.add(IssueMissingExport, offset, -1, "%v %q is not exported", kind, name)
+		case export.Kind != kind:
+			report.add(IssueMissingExport, offset, -1, "export %q is a %v, expected %v", name, export.Kind, kind)
+		}
+	}
+}
+
+func validateMemories(report *ValidationReport, m *Module, config *ValidationConfig) {
+	check := func(limits Limits, offset int) {
+		if limits.Min > config.MaxMemoryPages {
+			report.add(IssueMemoryTooLarge, offset, -1, "initial memory of %v pages exceeds %v", limits.Min, config.MaxMemoryPages)
+		}
+		if limits.Max != nil && *limits.Max > config.MaxMemoryPages {
+			report.add(IssueMemoryTooLarge, offset, -1, "maximum memory of %v pages exceeds %v", *limits.Max, config.MaxMemoryPages)
+		}
+	}
+	for _, imp := range m.Imports {
+		if imp.Kind == ExternalMemory {
+			check(imp.Limits, sectionOffset(m, SectionImport))
+		}
+	}
+	for _, limits := range m.Memories {
+		check(limits, sectionOffset(m, SectionMemory))
+	}
+}
+
+func validateFunctions(report *ValidationReport, m *Module, config *ValidationConfig) {
+	if !config.AllowFloats {
+		for i, t := range m.Types {
+			if hasFloat(t.Params) || hasFloat(t.Results) {
+				report.add(IssueFloat, sectionOffset(m, SectionType), -1, "type %v %v uses floats", i, t)
+			}
+		}
+		for i, g := range m.Globals {
+			if g.Type.IsFloat() {
+				report.add(IssueFloat, sectionOffset(m, SectionGlobal), -1, "global %v is %v", i, g.Type)
+			}
+		}
+	}
+	imported := m.ImportedFunctions()
+	for i, code := range m.Codes {
+		function := imported + i
+		instructions, err := Instructions(code.Expr, code.ExprOffset)
+		if err != nil {
+			report.add(IssueMalformed, code.Offset, function, "function %v: %v", function, err)
+			continue
+		}
+		if config.AllowFloats {
+			continue
+		}
+		for _, local := range code.Locals {
+			if local.Type.IsFloat() {
+				report.add(IssueFloat, code.Offset, function, "function %v has %v locals", function, local.Type)
+				break
+			}
+		}
+		floats, first := 0, 0
+		for _, ins := range instructions {
+			if ins.IsFloat() {
+				if floats == 0 {
+					first = ins.Offset
+				}
+				floats++
+			}
+		}
+		if floats > 0 {
+			report.add(IssueFloat, first, function, "function %v has %v float instructions", function, floats)
+		}
+	}
+}
+
+func hasFloat(types []ValueType) bool {
+	for _, t := range types {
+		if t.IsFloat() {
+			return true
+		}
+	}
+	return false
+}
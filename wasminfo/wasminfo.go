@@ -0,0 +1,177 @@
+// Package wasminfo describes contract code for explorers and tooling without running it.
+package wasminfo
+
+import (
+	"encoding/hex"
+	"github.com/idena-network/idena-wasm-binding/abi"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/wasm"
+	"sort"
+	"strings"
+)
+
+type Signature struct {
+	Params  []string `json:"params"`
+	Results []string `json:"results"`
+}
+
+func newSignature(t wasm.FuncType) *Signature {
+	res := &Signature{Params: []string{}, Results: []string{}}
+	for _, p := range t.Params {
+		res.Params = append(res.Params, p.String())
+	}
+	for _, r := range t.Results {
+		res.Results = append(res.Results, r.String())
+	}
+	return res
+}
+
+func (s *Signature) String() string {
+	res := "(" + strings.Join(s.Params, ", ") + ")"
+	if len(s.Results) > 0 {
+		res += " -> " + strings.Join(s.Results, ", ")
+	}
+	return res
+}
+
+type Export struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Signature is set for functions
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+type Import struct {
+	Module    string     `json:"module"`
+	Name      string     `json:"name"`
+	Kind      string     `json:"kind"`
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// Memory limits are in 64 KiB pages, Max is nil if the module does not declare it.
+type Memory struct {
+	Min      uint32  `json:"min"`
+	Max      *uint32 `json:"max,omitempty"`
+	Imported bool    `json:"imported,omitempty"`
+}
+
+type CustomSection struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// Function is a function defined by the module, Name comes from an export or the name section.
+type Function struct {
+	Index     uint32     `json:"index"`
+	Name      string     `json:"name,omitempty"`
+	Signature *Signature `json:"signature"`
+	CodeSize  int        `json:"codeSize"`
+}
+
+type Info struct {
+	// CodeHash is the 0x-prefixed hash contracts are deployed by
+	CodeHash       string          `json:"codeHash"`
+	Size           int             `json:"size"`
+	Exports        []Export        `json:"exports"`
+	Imports        []Import        `json:"imports"`
+	Memories       []Memory        `json:"memories"`
+	CustomSections []CustomSection `json:"customSections"`
+	Functions      []Function      `json:"functions"`
+	// Metadata is set if the code carries a valid abi.MetadataSection, MetadataError tells why it is invalid
+	Metadata      *abi.Metadata `json:"metadata,omitempty"`
+	MetadataError string        `json:"metadataError,omitempty"`
+}
+
+// runtimeExports are exported for the VM rather than called by Execute.
+var runtimeExports = map[string]bool{
+	"allocate": true,
+	"deploy":   true,
+}
+
+func Inspect(code []byte) (*Info, error) {
+	m, err := wasm.Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{
+		CodeHash:       "0x" + hex.EncodeToString(memory.CodeHash(code)),
+		Size:           len(code),
+		Exports:        []Export{},
+		Imports:        []Import{},
+		Memories:       []Memory{},
+		CustomSections: []CustomSection{},
+		Functions:      []Function{},
+	}
+	names := m.FunctionNames()
+	for _, e := range m.Exports {
+		export := Export{Name: e.Name, Kind: e.Kind.String()}
+		if e.Kind == wasm.ExternalFunction {
+			if t, ok := m.FunctionType(e.Index); ok {
+				export.Signature = newSignature(t)
+			}
+			if _, ok := names[e.Index]; !ok {
+				names[e.Index] = e.Name
+			}
+		}
+		info.Exports = append(info.Exports, export)
+	}
+	for _, imp := range m.Imports {
+		res := Import{Module: imp.Module, Name: imp.Name, Kind: imp.Kind.String()}
+		switch imp.Kind {
+		case wasm.ExternalFunction:
+			if int(imp.Type) < len(m.Types) {
+				res.Signature = newSignature(m.Types[imp.Type])
+			}
+		case wasm.ExternalMemory:
+			info.Memories = append(info.Memories, Memory{Min: imp.Limits.Min, Max: imp.Limits.Max, Imported: true})
+		}
+		info.Imports = append(info.Imports, res)
+	}
+	for _, limits := range m.Memories {
+		info.Memories = append(info.Memories, Memory{Min: limits.Min, Max: limits.Max})
+	}
+	for _, s := range m.CustomSections {
+		info.CustomSections = append(info.CustomSections, CustomSection{Name: s.Name, Size: len(s.Data)})
+	}
+	if data, ok := m.CustomSection(abi.MetadataSection); ok {
+		if info.Metadata, err = abi.ParseMetadata(data); err != nil {
+			info.MetadataError = err.Error()
+		}
+	}
+	imported := m.ImportedFunctions()
+	for i, c := range m.Codes {
+		index := uint32(imported + i)
+		t, _ := m.FunctionType(index)
+		info.Functions = append(info.Functions, Function{
+			Index:     index,
+			Name:      names[index],
+			Signature: newSignature(t),
+			CodeSize:  c.Size,
+		})
+	}
+	return info, nil
+}
+
+// Methods returns sorted names of exported functions which can be called by Execute.
+func (i *Info) Methods() []string {
+	var res []string
+	for _, e := range i.Exports {
+		if e.Signature == nil || runtimeExports[e.Name] || strings.HasPrefix(e.Name, "__") {
+			continue
+		}
+		res = append(res, e.Name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// HostFunctions returns names of functions imported from the host module.
+func (i *Info) HostFunctions() []string {
+	var res []string
+	for _, imp := range i.Imports {
+		if imp.Signature != nil && imp.Module == wasm.HostModule {
+			res = append(res, imp.Name)
+		}
+	}
+	return res
+}
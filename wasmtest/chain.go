@@ -0,0 +1,253 @@
+package wasmtest
+
+import (
+	"encoding/hex"
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"testing"
+)
+
+const DefaultGasLimit = 10000000
+const BlockInterval = 20
+const EpochBlocks = 1000
+
+var DefaultCaller = lib.Address{0x2}
+
+// Chain is a test fixture executing contracts against an in-memory state.
+// Every transaction runs against a copy of the state which replaces the chain state only if the transaction succeeds.
+type Chain struct {
+	t      testing.TB
+	state  *memory.State
+	block  memory.Block
+	nonces map[lib.Address]uint64
+	events []memory.Event
+
+	GasLimit uint64
+	Debug    bool
+	// Forks selects the gas schedule and enabled host functions by the chain block if set
+	Forks *lib.ForkConfig
+}
+
+func NewChain(t testing.TB) *Chain {
+	return &Chain{
+		t:     t,
+		state: memory.NewState(),
+		block: memory.Block{
+			Number:       1,
+			Timestamp:    1,
+			MinFeePerGas: big.NewInt(0),
+			NetworkSize:  1,
+			Headers:      map[uint64][]byte{},
+		},
+		nonces:   map[lib.Address]uint64{},
+		GasLimit: DefaultGasLimit,
+		Debug:    true,
+	}
+}
+
+func (c *Chain) State() *memory.State {
+	return c.state
+}
+
+// Block returns the current block which can be modified before the next transaction.
+func (c *Chain) Block() *memory.Block {
+	return &c.block
+}
+
+// Events returns events emitted by all successful transactions.
+func (c *Chain) Events() []memory.Event {
+	return c.events
+}
+
+func (c *Chain) SetBalance(addr lib.Address, balance *big.Int) {
+	c.state.SetBalance(addr, balance)
+}
+
+func (c *Chain) SetIdentity(addr lib.Address, identity []byte) {
+	c.state.SetIdentity(addr, identity)
+}
+
+func (c *Chain) AdvanceBlocks(n uint64) {
+	for i := uint64(0); i < n; i++ {
+		c.block.Number++
+		c.block.Timestamp += BlockInterval
+		if c.block.Number%EpochBlocks == 0 {
+			c.block.Epoch++
+		}
+	}
+}
+
+func (c *Chain) NextBlock() {
+	c.AdvanceBlocks(1)
+}
+
+func (c *Chain) NextEpoch() {
+	c.AdvanceBlocks(EpochBlocks - c.block.Number%EpochBlocks)
+}
+
+// From returns a transaction builder sending transactions from the caller.
+func (c *Chain) From(caller lib.Address) *Tx {
+	return &Tx{chain: c, caller: caller, amount: big.NewInt(0), gasLimit: c.GasLimit}
+}
+
+func (c *Chain) Deploy(code []byte, args ...[]byte) *Receipt {
+	return c.From(DefaultCaller).Deploy(code, args...)
+}
+
+func (c *Chain) DeployFile(path string, args ...[]byte) *Receipt {
+	return c.From(DefaultCaller).DeployFile(path, args...)
+}
+
+func (c *Chain) Call(contract lib.Address, method string, args ...[]byte) *Receipt {
+	return c.From(DefaultCaller).Call(contract, method, args...)
+}
+
+func (c *Chain) RequireStorage(contract lib.Address, key []byte, expected []byte) {
+	c.t.Helper()
+	actual := c.state.GetStorage(contract, key)
+	require.Equalf(c.t, hex.EncodeToString(expected), hex.EncodeToString(actual),
+		"unexpected storage value of contract %x, key %x (%q)", contract, key, key)
+}
+
+func (c *Chain) RequireNoStorage(contract lib.Address, key []byte) {
+	c.t.Helper()
+	actual := c.state.GetStorage(contract, key)
+	require.Nilf(c.t, actual, "contract %x is expected to have no value at key %x (%q), got %x", contract, key, key, actual)
+}
+
+func (c *Chain) RequireBalance(addr lib.Address, expected *big.Int) {
+	c.t.Helper()
+	actual := c.state.Balance(addr)
+	require.Truef(c.t, actual.Cmp(expected) == 0, "unexpected balance of %x: expected %v, actual %v", addr, expected, actual)
+}
+
+type Tx struct {
+	chain    *Chain
+	caller   lib.Address
+	amount   *big.Int
+	gasLimit uint64
+}
+
+func (tx *Tx) WithAmount(amount *big.Int) *Tx {
+	tx.amount = amount
+	return tx
+}
+
+func (tx *Tx) WithGasLimit(gasLimit uint64) *Tx {
+	tx.gasLimit = gasLimit
+	return tx
+}
+
+func (tx *Tx) DeployFile(path string, args ...[]byte) *Receipt {
+	tx.chain.t.Helper()
+	code, err := os.ReadFile(path)
+	require.NoErrorf(tx.chain.t, err, "cannot read contract code from %v", path)
+	return tx.Deploy(code, args...)
+}
+
+func (tx *Tx) Deploy(code []byte, args ...[]byte) *Receipt {
+	c := tx.chain
+	nonce := big.NewInt(int64(c.nonces[tx.caller])).Bytes()
+	contract := memory.ContractAddress(memory.CodeHash(code), lib.PackArguments(args), nonce)
+	env, state := tx.newEnv(contract, "deploy", true)
+	receipt := &Receipt{t: c.t, Contract: contract}
+	if env.ContractCodeHash(contract) != nil {
+		receipt.Err = lib.ErrAlreadyDeployed
+		return receipt
+	}
+	diffEnv := hostenv.NewDiffHostEnv(env, contract)
+	if err := diffEnv.Transfer(tx.caller, contract, tx.amount); err != nil {
+		receipt.Err = err
+		return receipt
+	}
+	diffEnv.Deploy(code)
+	api := tx.newAPI(diffEnv, receipt)
+	result, err := lib.DeployResult(api, code, args, contract, tx.gasLimit, c.Debug)
+	receipt.StateDiff = diffEnv.StateDiff()
+	return tx.finish(env, state, receipt, result, err)
+}
+
+func (tx *Tx) Call(contract lib.Address, method string, args ...[]byte) *Receipt {
+	c := tx.chain
+	env, state := tx.newEnv(contract, method, false)
+	receipt := &Receipt{t: c.t, Contract: contract}
+	code := env.GetCode(contract)
+	if len(code) == 0 {
+		receipt.Err = lib.ErrCodeEmpty
+		return receipt
+	}
+	diffEnv := hostenv.NewDiffHostEnv(env, contract)
+	if err := diffEnv.Transfer(tx.caller, contract, tx.amount); err != nil {
+		receipt.Err = err
+		return receipt
+	}
+	api := tx.newAPI(diffEnv, receipt)
+	result, err := lib.ExecuteResult(api, code, method, args, contract, tx.gasLimit, c.Debug)
+	receipt.StateDiff = diffEnv.StateDiff()
+	return tx.finish(env, state, receipt, result, err)
+}
+
+// EstimateCall finds the minimal gas limit of the call up to the transaction gas limit, the chain state is not changed.
+func (tx *Tx) EstimateCall(contract lib.Address, method string, args ...[]byte) (*lib.GasEstimate, error) {
+	c := tx.chain
+	code := c.state.Code(c.state.ContractCodeHash(contract))
+	if len(code) == 0 {
+		return nil, lib.ErrCodeEmpty
+	}
+	factory := func() (*lib.GoAPI, error) {
+		env, _ := tx.newEnv(contract, method, false)
+		if err := env.Transfer(tx.caller, contract, tx.amount); err != nil {
+			return nil, err
+		}
+		api := lib.NewGoAPI(env, &lib.GasMeter{})
+		api.SetForkConfig(c.Forks)
+		return api, nil
+	}
+	return lib.EstimateGas(factory, code, method, args, contract, tx.gasLimit, c.Debug)
+}
+
+func (tx *Tx) newAPI(env lib.HostEnv, receipt *Receipt) *lib.GoAPI {
+	api := lib.NewGoAPI(env, &lib.GasMeter{})
+	api.SetForkConfig(tx.chain.Forks)
+	receipt.GasProfile = lib.NewGasProfile(lib.DefaultStorageKeyPrefixLength)
+	api.SetGasProfile(receipt.GasProfile)
+	return api
+}
+
+func (tx *Tx) newEnv(contract lib.Address, method string, isDeploy bool) (*memory.Env, *memory.State) {
+	c := tx.chain
+	state := c.state.Copy()
+	block := c.block
+	env := memory.NewEnv(state, &block, memory.Context{
+		Caller:         tx.caller,
+		OriginalCaller: tx.caller,
+		Contract:       contract,
+		Method:         method,
+		PayAmount:      tx.amount,
+		IsDeploy:       isDeploy,
+	})
+	env.SetDebug(c.Debug)
+	return env, state
+}
+
+func (tx *Tx) finish(env *memory.Env, state *memory.State, receipt *Receipt, result *lib.ExecutionResult, err error) *Receipt {
+	c := tx.chain
+	c.nonces[tx.caller]++
+	receipt.Result = result
+	receipt.Err = err
+	if result != nil {
+		receipt.GasUsed = result.GasUsed
+	}
+	if err != nil {
+		return receipt
+	}
+	env.Commit()
+	receipt.Events = env.Events()
+	c.events = append(c.events, receipt.Events...)
+	c.state = state
+	return receipt
+}
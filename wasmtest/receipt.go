@@ -0,0 +1,134 @@
+package wasmtest
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/idena-network/idena-wasm-binding/hostenv"
+	"github.com/idena-network/idena-wasm-binding/hostenv/memory"
+	"github.com/idena-network/idena-wasm-binding/lib"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// Receipt is the outcome of a transaction sent to the Chain.
+type Receipt struct {
+	t testing.TB
+
+	Contract lib.Address
+	Result   *lib.ExecutionResult
+	Err      error
+	GasUsed  uint64
+	// Events holds events emitted by the transaction, it is empty if the transaction failed
+	Events []memory.Event
+	// StateDiff holds changes made by the execution, they are discarded if the transaction failed
+	StateDiff *hostenv.StateDiff
+	// GasProfile shows how gas of the execution is split between host functions, contracts and storage keys
+	GasProfile *lib.GasProfile
+}
+
+func (r *Receipt) Output() []byte {
+	if r.Result == nil {
+		return nil
+	}
+	return r.Result.OutputData
+}
+
+func (r *Receipt) RequireSuccess() *Receipt {
+	r.t.Helper()
+	if r.Err != nil {
+		r.t.Fatalf("transaction to %x failed: %v%v", r.Contract, r.Err, r.failureDetails())
+	}
+	return r
+}
+
+// RequireError fails the test if the transaction succeeded or failed with an error not matching kind.
+// Any error is accepted if kind is nil.
+func (r *Receipt) RequireError(kind error) *Receipt {
+	r.t.Helper()
+	if r.Err == nil {
+		r.t.Fatalf("transaction to %x is expected to fail", r.Contract)
+	}
+	if kind != nil && !errors.Is(r.Err, kind) {
+		r.t.Fatalf("transaction to %x is expected to fail with %q, got %q%v", r.Contract, kind, r.Err, r.failureDetails())
+	}
+	return r
+}
+
+func (r *Receipt) RequireOutput(expected []byte) *Receipt {
+	r.t.Helper()
+	require.Equalf(r.t, hex.EncodeToString(expected), hex.EncodeToString(r.Output()), "unexpected output of transaction to %x", r.Contract)
+	return r
+}
+
+// RequireEvent fails the test if the transaction did not emit an event with the name and arguments.
+func (r *Receipt) RequireEvent(name string, args ...[]byte) *Receipt {
+	r.t.Helper()
+	for _, event := range r.Events {
+		if event.Name == name && equalArgs(event.Args, args) {
+			return r
+		}
+	}
+	r.t.Fatalf("event %v(%v) is not emitted, emitted events:\n%v", name, formatArgs(args), formatEvents(r.Events))
+	return r
+}
+
+func (r *Receipt) RequireNoEvent(name string) *Receipt {
+	r.t.Helper()
+	for _, event := range r.Events {
+		if event.Name == name {
+			r.t.Fatalf("event %v is not expected, emitted events:\n%v", name, formatEvents(r.Events))
+		}
+	}
+	return r
+}
+
+func (r *Receipt) failureDetails() string {
+	if r.Result == nil {
+		return ""
+	}
+	node := r.Result.FailedNode()
+	if node == nil || node == r.Result {
+		return ""
+	}
+	method := ""
+	if node.Action != nil {
+		method = node.Action.Method
+	}
+	return "\nfailed at contract " + hex.EncodeToString(node.Contract[:]) + ", method " + method + ": " + node.Error
+}
+
+func equalArgs(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if (a[i] == nil) != (b[i] == nil) || string(a[i]) != string(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatArgs(args [][]byte) string {
+	formatted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == nil {
+			formatted = append(formatted, "nil")
+		} else {
+			formatted = append(formatted, "0x"+hex.EncodeToString(arg))
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func formatEvents(events []memory.Event) string {
+	if len(events) == 0 {
+		return "  <none>"
+	}
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, "  "+hex.EncodeToString(event.Contract[:])+": "+event.Name+"("+formatArgs(event.Args)+")")
+	}
+	return strings.Join(lines, "\n")
+}